@@ -5,15 +5,32 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/podhmo/astknife/action/append"
+	"github.com/podhmo/astknife/action/delete"
 	"github.com/podhmo/astknife/action/replace"
 	"github.com/podhmo/astknife/lookup"
 )
 
 // Append :
-func Append(k *lookup.Lookup, f *ast.File, r *lookup.Result) (ok bool, err error) {
+func Append(k *lookup.Lookup, f *ast.File, r *lookup.Result, options ...func(*Config)) (ok bool, err error) {
+	c := newConfig(options)
+	defer c.resolve(&err, r)
 	if r == nil {
 		return false, ErrReplacementNotFound
 	}
+	r = c.normalizeReceiver(k, f, r)
+	if err := c.checkApplied(f, r); err != nil {
+		return false, err
+	}
+	defer c.stamp(r)(&ok, &err)
+	defer c.journalize(f, "append", r)(&ok, &err)
+
+	dup, err := c.checkDuplicate(k, f, r)
+	if err != nil {
+		return false, err
+	}
+	if dup != nil {
+		return c.replaceDuplicate(f, dup, r)
+	}
 
 	switch r.Type {
 	case lookup.TypeToplevel:
@@ -26,10 +43,18 @@ func Append(k *lookup.Lookup, f *ast.File, r *lookup.Result) (ok bool, err error
 }
 
 // Replace :
-func Replace(k *lookup.Lookup, f *ast.File, r *lookup.Result) (ok bool, err error) {
+func Replace(k *lookup.Lookup, f *ast.File, r *lookup.Result, options ...func(*Config)) (ok bool, err error) {
+	c := newConfig(options)
+	defer c.resolve(&err, r)
 	if r == nil {
 		return false, ErrReplacementNotFound
 	}
+	r = c.normalizeReceiver(k, f, r)
+	if err := c.checkApplied(f, r); err != nil {
+		return false, err
+	}
+	defer c.stamp(r)(&ok, &err)
+	defer c.journalize(f, "replace", r)(&ok, &err)
 
 	switch r.Type {
 	case lookup.TypeToplevel:
@@ -37,12 +62,24 @@ func Replace(k *lookup.Lookup, f *ast.File, r *lookup.Result) (ok bool, err erro
 		if drObject == nil {
 			return false, ErrTargetNotFound
 		}
-		return replace.ToplevelToFile(f, drObject, r.Object)
+		if err := c.checkUnchanged(drObject.Decl.(ast.Node), r.Node()); err != nil {
+			return false, err
+		}
+		if err := c.checkProtected(f, r.Name(), drObject.Decl.(ast.Node)); err != nil {
+			return false, err
+		}
+		return c.replaceToplevel(f, drObject, r.Object)
 	case lookup.TypeMethod:
 		dr := k.MethodByObject(r.Object, r.Name())
 		if dr == nil {
 			return false, ErrTargetNotFound
 		}
+		if err := c.checkUnchanged(dr.FuncDecl, r.FuncDecl); err != nil {
+			return false, err
+		}
+		if err := c.checkProtected(f, r.Object.Name+"."+r.Name(), dr.FuncDecl); err != nil {
+			return false, err
+		}
 		return replace.MethodToFile(f, r.Object, dr.FuncDecl, r.FuncDecl)
 	default:
 		return false, errors.New("not implemented")
@@ -50,10 +87,18 @@ func Replace(k *lookup.Lookup, f *ast.File, r *lookup.Result) (ok bool, err erro
 }
 
 // AppendOrReplace : upsert
-func AppendOrReplace(k *lookup.Lookup, f *ast.File, r *lookup.Result) (ok bool, err error) {
+func AppendOrReplace(k *lookup.Lookup, f *ast.File, r *lookup.Result, options ...func(*Config)) (ok bool, err error) {
+	c := newConfig(options)
+	defer c.resolve(&err, r)
 	if r == nil {
 		return false, ErrReplacementNotFound
 	}
+	r = c.normalizeReceiver(k, f, r)
+	if err := c.checkApplied(f, r); err != nil {
+		return false, err
+	}
+	defer c.stamp(r)(&ok, &err)
+	defer c.journalize(f, "upsert", r)(&ok, &err)
 
 	switch r.Type {
 	case lookup.TypeToplevel:
@@ -61,14 +106,59 @@ func AppendOrReplace(k *lookup.Lookup, f *ast.File, r *lookup.Result) (ok bool,
 		if drObject == nil {
 			return append.ToplevelToFile(f, r.Object)
 		}
-		return replace.ToplevelToFile(f, drObject, r.Object)
+		if err := c.checkUnchanged(drObject.Decl.(ast.Node), r.Node()); err != nil {
+			return false, err
+		}
+		if err := c.checkProtected(f, r.Name(), drObject.Decl.(ast.Node)); err != nil {
+			return false, err
+		}
+		return c.replaceToplevel(f, drObject, r.Object)
 	case lookup.TypeMethod:
 		dr := k.MethodByObject(r.Object, r.Name())
 		if dr == nil {
 			return append.FunctionToFile(f, r.FuncDecl)
 		}
+		if err := c.checkUnchanged(dr.FuncDecl, r.FuncDecl); err != nil {
+			return false, err
+		}
+		if err := c.checkProtected(f, r.Object.Name+"."+r.Name(), dr.FuncDecl); err != nil {
+			return false, err
+		}
 		return replace.MethodToFile(f, r.Object, dr.FuncDecl, r.FuncDecl)
 	default:
 		return false, errors.New("not implemented")
 	}
 }
+
+// Delete :
+func Delete(k *lookup.Lookup, f *ast.File, r *lookup.Result, options ...func(*Config)) (ok bool, err error) {
+	c := newConfig(options)
+	defer c.resolve(&err, r)
+	if r == nil {
+		return false, ErrTargetNotFound
+	}
+	defer c.journalize(f, "delete", r)(&ok, &err)
+
+	switch r.Type {
+	case lookup.TypeToplevel:
+		drObject := f.Scope.Lookup(r.Name())
+		if drObject == nil {
+			return false, ErrTargetNotFound
+		}
+		if err := c.checkProtected(f, r.Name(), drObject.Decl.(ast.Node)); err != nil {
+			return false, err
+		}
+		return delete.ToplevelToFile(f, drObject)
+	case lookup.TypeMethod:
+		dr := k.MethodByObject(r.Object, r.Name())
+		if dr == nil {
+			return false, ErrTargetNotFound
+		}
+		if err := c.checkProtected(f, r.Object.Name+"."+r.Name(), dr.FuncDecl); err != nil {
+			return false, err
+		}
+		return delete.FunctionToFile(f, dr.FuncDecl)
+	default:
+		return false, errors.New("not implemented")
+	}
+}
@@ -4,7 +4,8 @@ import (
 	"go/ast"
 	"go/token"
 
-	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action/failure"
+	"github.com/podhmo/astknife/action/scope"
 )
 
 // todo: all object types support
@@ -27,16 +28,24 @@ func ToplevelToFile(dst *ast.File, ob *ast.Object) (ok bool, err error) {
 		return
 	}
 
-	if ob := dst.Scope.Lookup(ob.Name); ob != nil {
-		err = errors.Errorf("%s is already existed, in scope", ob.Name)
+	if existed := dst.Scope.Lookup(ob.Name); existed != nil {
+		err = &failure.AlreadyExistsError{
+			Detail: failure.Detail{Kind: ob.Kind, SourcePos: ob.Pos(), TargetPos: existed.Pos()},
+			Name:   ob.Name,
+		}
 		return
 	}
 	dst.Scope.Insert(ob)
 
 	switch ob.Kind {
-	case ast.Typ:
+	case ast.Typ, ast.Con, ast.Var:
+		if containsSpec(dst, ob.Decl) {
+			ok = true // e.g. `var x, y int`, already appended with other name
+			return
+		}
+		tok := map[ast.ObjKind]token.Token{ast.Typ: token.TYPE, ast.Con: token.CONST, ast.Var: token.VAR}[ob.Kind]
 		dst.Decls = append(dst.Decls, &ast.GenDecl{
-			Tok:   token.TYPE,
+			Tok:   tok,
 			Specs: []ast.Spec{ob.Decl.(ast.Spec)},
 		})
 		ok = true
@@ -44,10 +53,16 @@ func ToplevelToFile(dst *ast.File, ob *ast.Object) (ok bool, err error) {
 		if decl, can := ob.Decl.(*ast.FuncDecl); can {
 			return FunctionToFile(dst, decl)
 		}
-		err = errors.Errorf("unsupported object type %s (kind=%q)", ob.Type, ob.Kind)
+		err = &failure.UnsupportedKindError{
+			Detail: failure.Detail{Kind: ob.Kind, SourcePos: ob.Pos()},
+			Name:   ob.Name,
+		}
 		return
 	default:
-		err = errors.Errorf("unsupported object type %s (kind=%q)", ob.Type, ob.Kind)
+		err = &failure.UnsupportedKindError{
+			Detail: failure.Detail{Kind: ob.Kind, SourcePos: ob.Pos()},
+			Name:   ob.Name,
+		}
 		return
 	}
 	return
@@ -60,6 +75,58 @@ func FunctionToFile(dst *ast.File, decl *ast.FuncDecl) (ok bool, err error) {
 	}
 
 	dst.Decls = append(dst.Decls, decl)
+	scope.Bind(dst, decl)
+	ok = true
+	return
+}
+
+// GenDeclToFile : append the copy of decl, as a whole (e.g. const group using iota)
+func GenDeclToFile(dst *ast.File, decl *ast.GenDecl) (ok bool, err error) {
+	if decl == nil {
+		return
+	}
+	for _, spec := range decl.Specs {
+		if containsSpec(dst, spec) {
+			return
+		}
+		for _, ident := range specNames(spec) {
+			if existed := dst.Scope.Lookup(ident.Name); existed != nil && ident.Name != "_" {
+				err = &failure.AlreadyExistsError{
+					Detail: failure.Detail{Kind: existed.Kind, SourcePos: ident.Pos(), TargetPos: existed.Pos()},
+					Name:   ident.Name,
+				}
+				return
+			}
+		}
+	}
+
+	copied := *decl
+	copied.Specs = append([]ast.Spec(nil), decl.Specs...)
+	dst.Decls = append(dst.Decls, &copied)
+	scope.Bind(dst, &copied)
 	ok = true
 	return
 }
+
+func containsSpec(dst *ast.File, node interface{}) bool {
+	for _, decl := range dst.Decls {
+		if decl, can := decl.(*ast.GenDecl); can {
+			for _, spec := range decl.Specs {
+				if spec == node {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func specNames(spec ast.Spec) []*ast.Ident {
+	switch t := spec.(type) {
+	case *ast.TypeSpec:
+		return []*ast.Ident{t.Name}
+	case *ast.ValueSpec:
+		return t.Names
+	}
+	return nil
+}
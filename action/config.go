@@ -0,0 +1,218 @@
+package action
+
+import (
+	"go/ast"
+	"go/token"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action/failure"
+	"github.com/podhmo/astknife/annotation"
+	"github.com/podhmo/astknife/equal"
+	"github.com/podhmo/astknife/fingerprint"
+	"github.com/podhmo/astknife/journal"
+	"github.com/podhmo/astknife/lookup"
+)
+
+// Config : options of actions
+type Config struct {
+	// Fset : if set, positions of errors are resolved (the replacement is resolved with its own FileSet, if lookup.Result.Fset is set)
+	Fset *token.FileSet
+
+	// Protect : if true, the declarations marked as `//astknife:keep` in destination, or listed in Protected, are never touched
+	Protect   bool
+	Protected map[string]bool // name (e.g. "S", "S.String")
+
+	KindPolicy KindPolicy
+
+	// ReplaceDuplicates : if true, Append replaces the declaration already defined in any files, instead of failing
+	ReplaceDuplicates bool
+	// DestinationOnly : if true, the duplicates defined in other files are never replaced (e.g. in transaction, only destination is restored)
+	DestinationOnly bool
+
+	// Journal : if set, before and after source text of touched declarations are recorded (Fset is required)
+	Journal *journal.Journal
+
+	// Manifest : if set, applied declarations are fingerprinted, and already applied ones are skipped
+	Manifest *fingerprint.Manifest
+
+	// DryRun : if true, Journal and Manifest are never recorded (Manifest is still used to skip already applied ones)
+	DryRun bool
+
+	// IgnoreComments : if true, comments are ignored, when checking the replacement is unchanged or not
+	IgnoreComments bool
+
+	// NormalizeReceiver : if true, the receiver name of method is rewritten to the prevailing one of the target type
+	NormalizeReceiver bool
+	// NormalizeReceiverPointer : if true, pointer or value receiver of method is adjusted to the prevailing one of the target type
+	NormalizeReceiverPointer bool
+}
+
+// WithFileSet :
+func WithFileSet(fset *token.FileSet) func(*Config) {
+	return func(c *Config) {
+		c.Fset = fset
+	}
+}
+
+// WithReplaceDuplicates : Append replaces the duplicated declaration, instead of failing
+func WithReplaceDuplicates() func(*Config) {
+	return func(c *Config) {
+		c.ReplaceDuplicates = true
+	}
+}
+
+// WithDestinationOnly : never touch the files other than destination (even with WithReplaceDuplicates)
+func WithDestinationOnly() func(*Config) {
+	return func(c *Config) {
+		c.DestinationOnly = true
+	}
+}
+
+// WithJournal : the FileSet is required, to print the source text of touched declarations
+func WithJournal(fset *token.FileSet, j *journal.Journal) func(*Config) {
+	return func(c *Config) {
+		c.Fset = fset
+		c.Journal = j
+	}
+}
+
+// WithManifest :
+func WithManifest(m *fingerprint.Manifest) func(*Config) {
+	return func(c *Config) {
+		c.Manifest = m
+	}
+}
+
+// WithProtect : never touch the declarations marked as `//astknife:keep` or listed in names
+func WithProtect(names ...string) func(*Config) {
+	return func(c *Config) {
+		c.Protect = true
+		if c.Protected == nil {
+			c.Protected = map[string]bool{}
+		}
+		for _, name := range names {
+			c.Protected[name] = true
+		}
+	}
+}
+
+// WithDryRun : the changes are not recorded in Journal and Manifest (e.g. planning, which is rolled back)
+func WithDryRun() func(*Config) {
+	return func(c *Config) {
+		c.DryRun = true
+	}
+}
+
+// WithIgnoreComments : treat the replacement that differs only in comments as unchanged
+func WithIgnoreComments() func(*Config) {
+	return func(c *Config) {
+		c.IgnoreComments = true
+	}
+}
+
+func newConfig(options []func(*Config)) *Config {
+	c := &Config{KindPolicy: KindPolicyError}
+	for _, op := range options {
+		op(c)
+	}
+	return c
+}
+
+// resolve : resolve positions of failure
+func (c *Config) resolve(err *error, r *lookup.Result) {
+	if x, ok := errors.Cause(*err).(failure.Resolver); ok {
+		x.Resolve(c.Fset, r)
+	}
+}
+
+// journalize : the before text is captured at call, and the entry is recorded if the action is succeeded
+func (c *Config) journalize(f *ast.File, op string, r *lookup.Result) func(ok *bool, err *error) {
+	if c.Journal == nil || c.DryRun {
+		return func(ok *bool, err *error) {}
+	}
+	if c.Fset == nil {
+		return func(ok *bool, err *error) {
+			if *err == nil {
+				*err = ErrFileSetRequired
+			}
+		}
+	}
+	record := c.Journal.Recorder(c.Fset, f, op, r.FullName())
+	return func(ok *bool, err *error) {
+		if *ok && *err == nil {
+			*err = record()
+		}
+	}
+}
+
+// checkApplied : returns ErrAlreadyApplied if the replacement is already applied, and not drifted since applied
+func (c *Config) checkApplied(f *ast.File, r *lookup.Result) error {
+	if c.Manifest == nil {
+		return nil
+	}
+	current, _ := lookup.FindInFile(f, r.FullName())
+	if c.Manifest.Status(r.FullName(), current, r.Node()) == fingerprint.StatusApplied {
+		return ErrAlreadyApplied
+	}
+	return nil
+}
+
+// stamp : the fingerprint is recorded if the action is succeeded
+func (c *Config) stamp(r *lookup.Result) func(ok *bool, err *error) {
+	return func(ok *bool, err *error) {
+		if c.Manifest != nil && !c.DryRun && *ok && *err == nil {
+			c.Manifest.Record(r.FullName(), r.Node())
+		}
+	}
+}
+
+// checkProtected : returns ConflictError if dst node (*ast.FuncDecl or ast.Spec) is protected
+func (c *Config) checkProtected(f *ast.File, name string, node ast.Node) error {
+	if !c.Protect {
+		return nil
+	}
+	if c.Protected[name] {
+		return &ConflictError{Detail: failure.Detail{TargetPos: node.Pos()}, Name: name, Reason: "listed in protect list"}
+	}
+
+	var docs []*ast.CommentGroup
+	switch t := node.(type) {
+	case *ast.FuncDecl:
+		docs = append(docs, t.Doc)
+	case *ast.TypeSpec:
+		docs = append(docs, t.Doc, findGenDecl(f, t).Doc)
+	case *ast.ValueSpec:
+		docs = append(docs, t.Doc, findGenDecl(f, t).Doc)
+	}
+	for _, doc := range docs {
+		if annotation.Has(doc, annotation.KindKeep) {
+			return &ConflictError{Detail: failure.Detail{TargetPos: node.Pos()}, Name: name, Reason: "marked as keep"}
+		}
+	}
+	return nil
+}
+
+// checkUnchanged : returns ErrUnchanged if replacement is structurally identical to dst node
+func (c *Config) checkUnchanged(dst ast.Node, replacement ast.Node) error {
+	var options []func(*equal.Config)
+	if c.IgnoreComments {
+		options = append(options, equal.WithIgnoreComments())
+	}
+	if equal.Node(dst, replacement, options...) {
+		return ErrUnchanged
+	}
+	return nil
+}
+
+func findGenDecl(f *ast.File, spec ast.Spec) *ast.GenDecl {
+	for _, decl := range f.Decls {
+		if decl, ok := decl.(*ast.GenDecl); ok {
+			for _, s := range decl.Specs {
+				if s == spec {
+					return decl
+				}
+			}
+		}
+	}
+	return &ast.GenDecl{}
+}
@@ -0,0 +1,93 @@
+package delete
+
+import (
+	"go/ast"
+
+	"github.com/podhmo/astknife/action/failure"
+	"github.com/podhmo/astknife/action/scope"
+)
+
+// ToplevelToFile :
+func ToplevelToFile(dst *ast.File, ob *ast.Object) (ok bool, err error) {
+	if ob == nil {
+		return
+	}
+
+	switch ob.Kind {
+	case ast.Typ, ast.Con, ast.Var:
+		spec, can := ob.Decl.(ast.Spec)
+		if !can {
+			err = &failure.UnsupportedKindError{
+				Detail: failure.Detail{Kind: ob.Kind, TargetPos: ob.Pos()},
+				Name:   ob.Name,
+			}
+			return
+		}
+		ok, err = SpecToFile(dst, spec)
+	case ast.Fun:
+		decl, can := ob.Decl.(*ast.FuncDecl)
+		if !can {
+			err = &failure.UnsupportedKindError{
+				Detail: failure.Detail{Kind: ob.Kind, TargetPos: ob.Pos()},
+				Name:   ob.Name,
+			}
+			return
+		}
+		ok, err = FunctionToFile(dst, decl)
+	default:
+		err = &failure.UnsupportedKindError{
+			Detail: failure.Detail{Kind: ob.Kind, TargetPos: ob.Pos()},
+			Name:   ob.Name,
+		}
+	}
+	return
+}
+
+// SpecToFile : delete spec, and delete the GenDecl if it becomes empty
+func SpecToFile(dst *ast.File, dstSpec ast.Spec) (ok bool, err error) {
+	for i, decl := range dst.Decls {
+		decl, can := decl.(*ast.GenDecl)
+		if !can {
+			continue
+		}
+		for j, spec := range decl.Specs {
+			if spec != dstSpec {
+				continue
+			}
+			scope.Unbind(dst, dstSpec)
+			if len(decl.Specs) == 1 {
+				dst.Decls = removeDecl(dst.Decls, i)
+			} else {
+				// copy, not to modify the backing array shared with others
+				newspecs := make([]ast.Spec, 0, len(decl.Specs)-1)
+				newspecs = append(newspecs, decl.Specs[:j]...)
+				decl.Specs = append(newspecs, decl.Specs[j+1:]...)
+			}
+			ok = true
+			return
+		}
+	}
+	return
+}
+
+// FunctionToFile :
+func FunctionToFile(dst *ast.File, dstDecl *ast.FuncDecl) (ok bool, err error) {
+	if dstDecl == nil {
+		return
+	}
+	for i, decl := range dst.Decls {
+		if decl == dstDecl {
+			scope.Unbind(dst, dstDecl)
+			dst.Decls = removeDecl(dst.Decls, i)
+			ok = true
+			return
+		}
+	}
+	return
+}
+
+func removeDecl(decls []ast.Decl, i int) []ast.Decl {
+	newdecls := make([]ast.Decl, 0, len(decls)-1)
+	newdecls = append(newdecls, decls[:i]...)
+	return append(newdecls, decls[i+1:]...)
+}
@@ -0,0 +1,91 @@
+package action
+
+import (
+	"go/ast"
+
+	"github.com/podhmo/astknife/action/failure"
+	"github.com/podhmo/astknife/action/replace"
+	"github.com/podhmo/astknife/lookup"
+)
+
+// duplicate : the declaration of the same name, already defined in dst or other files
+type duplicate struct {
+	File     *ast.File
+	Object   *ast.Object   // toplevel
+	FuncDecl *ast.FuncDecl // method
+}
+
+func (d *duplicate) node() ast.Node {
+	if d.FuncDecl != nil {
+		return d.FuncDecl
+	}
+	return d.Object.Decl.(ast.Node)
+}
+
+// findDuplicate : find the declaration of the same name as r, in f and the files of k (except r itself)
+func findDuplicate(k *lookup.Lookup, f *ast.File, r *lookup.Result) *duplicate {
+	files := []*ast.File{f}
+	if k != nil {
+		for _, file := range k.Files {
+			if file != f {
+				files = append(files, file)
+			}
+		}
+	}
+
+	switch r.Type {
+	case lookup.TypeToplevel:
+		for _, file := range files {
+			if ob := file.Scope.Lookup(r.Name()); ob != nil && ob != r.Object && ob.Decl != r.Object.Decl {
+				return &duplicate{File: file, Object: ob}
+			}
+		}
+	case lookup.TypeMethod:
+		recv := lookup.ReceiverName(r.FuncDecl)
+		for _, file := range files {
+			for _, decl := range file.Decls {
+				if decl, ok := decl.(*ast.FuncDecl); ok && decl != r.FuncDecl && lookup.IsMethod(decl) {
+					if decl.Name.Name == r.Name() && lookup.ReceiverName(decl) == recv {
+						return &duplicate{File: file, FuncDecl: decl}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkDuplicate : if duplicated, fail with failure.AlreadyExistsError (or returns it to be replaced, with WithReplaceDuplicates).
+// with WithDestinationOnly, the duplicate in other files is always failed
+func (c *Config) checkDuplicate(k *lookup.Lookup, f *ast.File, r *lookup.Result) (dup *duplicate, err error) {
+	dup = findDuplicate(k, f, r)
+	if dup == nil || (c.ReplaceDuplicates && (!c.DestinationOnly || dup.File == f)) {
+		return dup, nil
+	}
+	kind := ast.Fun
+	if r.Object != nil && r.Type == lookup.TypeToplevel {
+		kind = r.Object.Kind
+	}
+	return dup, &failure.AlreadyExistsError{
+		Detail: failure.Detail{Kind: kind, SourcePos: r.Node().Pos(), TargetPos: dup.node().Pos()},
+		Name:   r.FullName(),
+	}
+}
+
+// replaceDuplicate : replace the duplicated declaration, in the file defining it (checked as same as Replace).
+// the change in other file than f is journaled as the entry of that file
+func (c *Config) replaceDuplicate(f *ast.File, dup *duplicate, r *lookup.Result) (ok bool, err error) {
+	if dup.File != f {
+		defer c.journalize(dup.File, "replace", r)(&ok, &err)
+	}
+	if err := c.checkUnchanged(dup.node(), r.Node()); err != nil {
+		return false, err
+	}
+	if err := c.checkProtected(dup.File, r.FullName(), dup.node()); err != nil {
+		return false, err
+	}
+	if dup.FuncDecl != nil {
+		return replace.MethodToFile(dup.File, r.Object, dup.FuncDecl, r.FuncDecl)
+	}
+	return c.replaceToplevel(dup.File, dup.Object, r.Object)
+}
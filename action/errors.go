@@ -1,20 +1,51 @@
 package action
 
-import "github.com/pkg/errors"
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action/failure"
+)
 
 var (
 	// ErrReplacementNotFound :
 	ErrReplacementNotFound = errors.New("replacement not found")
 	// ErrTargetNotFound :
 	ErrTargetNotFound = errors.New("target not found")
+	// ErrUnchanged : replacement is structurally identical to the target
+	ErrUnchanged = errors.New("unchanged")
+	// ErrAlreadyApplied : the replacement is already applied (recorded in fingerprint manifest)
+	ErrAlreadyApplied = errors.New("already applied")
+	// ErrFileSetRequired : the journal is set, but FileSet is not (see WithJournal)
+	ErrFileSetRequired = errors.New("FileSet is required to journalize")
 )
 
+// ConflictError : the target is protected, so the action is not applied
+type ConflictError struct {
+	failure.Detail
+	Name   string
+	Reason string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Target.IsValid() {
+		return fmt.Sprintf("%s: %s is protected (%s), in destination", e.Target, e.Name, e.Reason)
+	}
+	return fmt.Sprintf("%s is protected (%s), in destination", e.Name, e.Reason)
+}
+
 // IsNoEffect :
 func IsNoEffect(err error) bool {
 	switch errors.Cause(err) {
-	case ErrReplacementNotFound, ErrTargetNotFound:
+	case ErrReplacementNotFound, ErrTargetNotFound, ErrUnchanged, ErrAlreadyApplied:
 		return true
 	default:
 		return false
 	}
 }
+
+// IsConflict :
+func IsConflict(err error) bool {
+	_, ok := errors.Cause(err).(*ConflictError)
+	return ok
+}
@@ -0,0 +1,92 @@
+package failure
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/podhmo/astknife/lookup"
+)
+
+// Resolver : failure with unresolved positions
+type Resolver interface {
+	Resolve(fset *token.FileSet, r *lookup.Result)
+}
+
+// Detail : common information of action failures
+type Detail struct {
+	Result *lookup.Result // replacement
+	Kind   ast.ObjKind    // kind of replacement
+
+	SourcePos token.Pos // position of replacement
+	TargetPos token.Pos // position of target in destination
+	Source    token.Position
+	Target    token.Position
+}
+
+// Resolve : fill result and positions. the position of replacement is resolved with the FileSet of r, if known
+func (d *Detail) Resolve(fset *token.FileSet, r *lookup.Result) {
+	if d.Result == nil {
+		d.Result = r
+	}
+	srcFset := fset
+	if r != nil && r.Fset != nil {
+		srcFset = r.Fset
+	}
+	if d.SourcePos.IsValid() && srcFset != nil {
+		d.Source = srcFset.Position(d.SourcePos)
+	}
+	if d.TargetPos.IsValid() && fset != nil {
+		d.Target = fset.Position(d.TargetPos)
+	}
+}
+
+func (d *Detail) prefix() string {
+	if d.Source.IsValid() {
+		return d.Source.String() + ": "
+	}
+	return ""
+}
+
+func (d *Detail) suffix() string {
+	if d.Target.IsValid() {
+		return " (target " + d.Target.String() + ")"
+	}
+	return ""
+}
+
+// AlreadyExistsError :
+type AlreadyExistsError struct {
+	Detail
+	Name string
+}
+
+func (e *AlreadyExistsError) Error() string {
+	return fmt.Sprintf("%s%s is already existed, in scope%s", e.prefix(), e.Name, e.suffix())
+}
+
+// UnsupportedKindError :
+type UnsupportedKindError struct {
+	Detail
+	Name string
+}
+
+func (e *UnsupportedKindError) Error() string {
+	return fmt.Sprintf("%sunsupported object %s (kind=%q)%s", e.prefix(), e.Name, e.Kind, e.suffix())
+}
+
+// KindMismatchError : kind of replacement and kind of target are different
+type KindMismatchError struct {
+	Detail
+	Name       string
+	TargetKind ast.ObjKind
+	Note       string // e.g. "replacement is alias"
+}
+
+func (e *KindMismatchError) Error() string {
+	note := ""
+	if e.Note != "" {
+		note = ", " + e.Note
+	}
+	return fmt.Sprintf("%skind mismatch %s, replacement is %q but target is %q%s%s", e.prefix(), e.Name, e.Kind, e.TargetKind, note, e.suffix())
+}
@@ -0,0 +1,63 @@
+package action
+
+import (
+	"go/ast"
+
+	"github.com/podhmo/astknife/action/failure"
+	"github.com/podhmo/astknife/action/replace"
+)
+
+// KindPolicy : policy for replacing with the replacement of different kind
+type KindPolicy string
+
+const (
+	// KindPolicyError : kind changes are reported as failure.KindMismatchError (default).
+	// replacing a type with an alias (or an alias with a type) is also a kind change, use KindPolicyAlias to allow it
+	KindPolicyError = KindPolicy("error")
+	// KindPolicyAlias : allow replacing a type with an alias, and vice versa
+	KindPolicyAlias = KindPolicy("alias")
+	// KindPolicyConvert : allow replacing a func with a var, and a func replacing a var is converted to a var of func type
+	KindPolicyConvert = KindPolicy("convert")
+)
+
+// WithKindPolicy :
+func WithKindPolicy(policy KindPolicy) func(*Config) {
+	return func(c *Config) {
+		c.KindPolicy = policy
+	}
+}
+
+// replaceToplevel : replace.ToplevelToFile with kind policy
+func (c *Config) replaceToplevel(f *ast.File, dstOb *ast.Object, ob *ast.Object) (ok bool, err error) {
+	switch {
+	case dstOb.Kind == ast.Typ && ob.Kind == ast.Typ && isAlias(dstOb) != isAlias(ob):
+		if c.KindPolicy != KindPolicyAlias {
+			note := "replacement is alias"
+			if isAlias(dstOb) {
+				note = "target is alias"
+			}
+			return false, &failure.KindMismatchError{
+				Detail:     failure.Detail{Kind: ob.Kind, SourcePos: ob.Pos(), TargetPos: dstOb.Pos()},
+				Name:       ob.Name,
+				TargetKind: dstOb.Kind,
+				Note:       note,
+			}
+		}
+		return replace.ToplevelToFile(f, dstOb, ob)
+	case c.KindPolicy == KindPolicyConvert && dstOb.Kind == ast.Fun && ob.Kind == ast.Var:
+		dstDecl, _ := dstOb.Decl.(*ast.FuncDecl)
+		spec, _ := ob.Decl.(*ast.ValueSpec)
+		return replace.FunctionToVar(f, dstDecl, spec)
+	case c.KindPolicy == KindPolicyConvert && dstOb.Kind == ast.Var && ob.Kind == ast.Fun:
+		dstSpec, _ := dstOb.Decl.(*ast.ValueSpec)
+		decl, _ := ob.Decl.(*ast.FuncDecl)
+		return replace.VarToFunction(f, dstSpec, decl)
+	default:
+		return replace.ToplevelToFile(f, dstOb, ob) // kind mismatch is reported here
+	}
+}
+
+func isAlias(ob *ast.Object) bool {
+	spec, ok := ob.Decl.(*ast.TypeSpec)
+	return ok && spec.Assign.IsValid()
+}
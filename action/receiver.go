@@ -0,0 +1,139 @@
+package action
+
+import (
+	"go/ast"
+
+	"github.com/podhmo/astknife/clone"
+	"github.com/podhmo/astknife/lookup"
+)
+
+// WithNormalizeReceiver : rewrite the receiver name of method (and its uses in the body), to the prevailing one of the target type
+func WithNormalizeReceiver() func(*Config) {
+	return func(c *Config) {
+		c.NormalizeReceiver = true
+	}
+}
+
+// WithNormalizeReceiverPointer : adjust pointer or value receiver of method, to the prevailing one of the target type
+func WithNormalizeReceiverPointer() func(*Config) {
+	return func(c *Config) {
+		c.NormalizeReceiverPointer = true
+	}
+}
+
+// normalizeReceiver : returns the result of normalized copy of method, or r itself if nothing is changed.
+// if the prevailing name is already used in the body for other things, the name is kept
+func (c *Config) normalizeReceiver(k *lookup.Lookup, f *ast.File, r *lookup.Result) *lookup.Result {
+	if !(c.NormalizeReceiver || c.NormalizeReceiverPointer) || r.Type != lookup.TypeMethod || r.FuncDecl.Recv == nil || len(r.FuncDecl.Recv.List) == 0 {
+		return r
+	}
+	name, pointer, found := prevailingReceiver(k, f, lookup.ReceiverName(r.FuncDecl), r.FuncDecl)
+	if !found {
+		return r
+	}
+
+	field := r.FuncDecl.Recv.List[0]
+	current := ""
+	if len(field.Names) > 0 {
+		current = field.Names[0].Name
+	}
+	_, isPointer := field.Type.(*ast.StarExpr)
+	rename := c.NormalizeReceiver && name != "" && current != name
+	repoint := c.NormalizeReceiverPointer && pointer != isPointer
+	if !rename && !repoint {
+		return r
+	}
+
+	decl := clone.Node(r.FuncDecl).(*ast.FuncDecl)
+	field = decl.Recv.List[0]
+	if rename {
+		var ob *ast.Object
+		if len(field.Names) > 0 && field.Names[0].Name != "_" {
+			ob = field.Names[0].Obj
+		}
+		if !usedInBody(decl, name, ob) {
+			if len(field.Names) == 0 {
+				field.Names = []*ast.Ident{ast.NewIdent(name)}
+			}
+			field.Names[0].Name = name
+			if ob != nil {
+				ob.Name = name
+				ast.Inspect(decl.Body, func(node ast.Node) bool {
+					if ident, ok := node.(*ast.Ident); ok && ident.Obj == ob {
+						ident.Name = name
+					}
+					return true
+				})
+			}
+		}
+	}
+	if repoint {
+		if pointer {
+			field.Type = &ast.StarExpr{Star: field.Type.Pos(), X: field.Type}
+		} else {
+			field.Type = field.Type.(*ast.StarExpr).X
+		}
+	}
+	return &lookup.Result{Type: r.Type, Object: r.Object, FuncDecl: decl, Fset: r.Fset}
+}
+
+// prevailingReceiver : the most used receiver name, and whether the majority is pointer receiver, in the methods of typename in f and the files of k
+func prevailingReceiver(k *lookup.Lookup, f *ast.File, typename string, exclude *ast.FuncDecl) (name string, pointer bool, found bool) {
+	files := []*ast.File{f}
+	if k != nil {
+		for _, file := range k.Files {
+			if file != f {
+				files = append(files, file)
+			}
+		}
+	}
+
+	counts := map[string]int{}
+	total, pointers := 0, 0
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			decl, ok := decl.(*ast.FuncDecl)
+			if !ok || decl == exclude || !lookup.IsMethod(decl) || lookup.ReceiverName(decl) != typename {
+				continue
+			}
+			field := decl.Recv.List[0]
+			total++
+			if _, ok := field.Type.(*ast.StarExpr); ok {
+				pointers++
+			}
+			if len(field.Names) > 0 && field.Names[0].Name != "_" {
+				n := field.Names[0].Name
+				counts[n]++
+				if counts[n] > counts[name] {
+					name = n
+				}
+			}
+		}
+	}
+	return name, pointers*2 >= total, total > 0
+}
+
+// usedInBody : the name is used in the body, as other than ob (selectors and keys of composite literals are ignored)
+func usedInBody(decl *ast.FuncDecl, name string, ob *ast.Object) bool {
+	if decl.Body == nil {
+		return false
+	}
+	ignored := map[*ast.Ident]bool{}
+	used := false
+	ast.Inspect(decl.Body, func(node ast.Node) bool {
+		switch t := node.(type) {
+		case *ast.SelectorExpr:
+			ignored[t.Sel] = true
+		case *ast.KeyValueExpr:
+			if key, ok := t.Key.(*ast.Ident); ok {
+				ignored[key] = true
+			}
+		case *ast.Ident:
+			if t.Name == name && !ignored[t] && (ob == nil || t.Obj != ob) {
+				used = true
+			}
+		}
+		return !used
+	})
+	return used
+}
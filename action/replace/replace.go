@@ -2,8 +2,10 @@ package replace
 
 import (
 	"go/ast"
+	"go/token"
 
-	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action/failure"
+	"github.com/podhmo/astknife/action/scope"
 	"github.com/podhmo/astknife/lookup"
 )
 
@@ -27,33 +29,57 @@ func ToplevelToFile(dst *ast.File, dstOb *ast.Object, ob *ast.Object) (ok bool,
 		return
 	}
 
+	if dstOb.Kind != ob.Kind {
+		err = &failure.KindMismatchError{
+			Detail:     failure.Detail{Kind: ob.Kind, SourcePos: ob.Pos(), TargetPos: dstOb.Pos()},
+			Name:       ob.Name,
+			TargetKind: dstOb.Kind,
+		}
+		return
+	}
+
 	switch ob.Kind {
-	case ast.Typ:
+	case ast.Typ, ast.Con, ast.Var:
 		dstSpec, can := dstOb.Decl.(ast.Spec)
 		if !can {
-			err = errors.Errorf("invalid object type %s dst (kind=%q)", ob.Type, ob.Kind) // xxx
+			err = &failure.UnsupportedKindError{
+				Detail: failure.Detail{Kind: ob.Kind, SourcePos: ob.Pos(), TargetPos: dstOb.Pos()},
+				Name:   ob.Name,
+			}
 			return
 		}
 		replacement, can := ob.Decl.(ast.Spec)
 		if !can {
-			err = errors.Errorf("invalid object type %s replacement (kind=%q)", ob.Type, ob.Kind) // xxx
+			err = &failure.UnsupportedKindError{
+				Detail: failure.Detail{Kind: ob.Kind, SourcePos: ob.Pos(), TargetPos: dstOb.Pos()},
+				Name:   ob.Name,
+			}
 			return
 		}
 		return SpecToFile(dst, dstSpec, replacement)
 	case ast.Fun:
 		dstDecl, can := dstOb.Decl.(*ast.FuncDecl)
 		if !can {
-			err = errors.Errorf("invalid object type %s dst (kind=%q)", ob.Type, ob.Kind) // xxx
+			err = &failure.UnsupportedKindError{
+				Detail: failure.Detail{Kind: ob.Kind, SourcePos: ob.Pos(), TargetPos: dstOb.Pos()},
+				Name:   ob.Name,
+			}
 			return
 		}
 		replacement, can := ob.Decl.(*ast.FuncDecl)
 		if !can {
-			err = errors.Errorf("invalid object type %s replacement (kind=%q)", ob.Type, ob.Kind) // xxx
+			err = &failure.UnsupportedKindError{
+				Detail: failure.Detail{Kind: ob.Kind, SourcePos: ob.Pos(), TargetPos: dstOb.Pos()},
+				Name:   ob.Name,
+			}
 			return
 		}
 		return FunctionToFile(dst, dstDecl, replacement)
 	default:
-		err = errors.Errorf("unsupported object type %s (kind=%q)", ob.Type, ob.Kind)
+		err = &failure.UnsupportedKindError{
+			Detail: failure.Detail{Kind: ob.Kind, SourcePos: ob.Pos(), TargetPos: dstOb.Pos()},
+			Name:   ob.Name,
+		}
 		return
 	}
 }
@@ -68,6 +94,8 @@ func SpecToFile(dst *ast.File, dstSpec ast.Spec, replacement ast.Spec) (ok bool,
 				if spec == dstSpec {
 					ok = true
 					newspec[i] = replacement
+					scope.Unbind(dst, dstSpec)
+					scope.Bind(dst, replacement)
 				} else {
 					newspec[i] = spec
 				}
@@ -90,6 +118,8 @@ func FunctionToFile(dst *ast.File, dstDecl *ast.FuncDecl, replacement *ast.FuncD
 	for i, decl := range dst.Decls {
 		if decl == dstDecl {
 			dst.Decls[i] = replacement
+			scope.Unbind(dst, dstDecl)
+			scope.Bind(dst, replacement)
 			ok = true
 			return
 		}
@@ -112,3 +142,66 @@ func MethodToFile(dst *ast.File, ob *ast.Object, dstDecl *ast.FuncDecl, replacem
 	}
 	return
 }
+
+// FunctionToVar : replace the function with the var declaration (e.g. `func F() {}` -> `var F = ...`)
+func FunctionToVar(dst *ast.File, dstDecl *ast.FuncDecl, replacement *ast.ValueSpec) (ok bool, err error) {
+	if replacement == nil {
+		return
+	}
+	if !isFuncTyped(replacement, dstDecl.Name.Name) {
+		err = &failure.KindMismatchError{
+			Detail:     failure.Detail{Kind: ast.Var, SourcePos: replacement.Pos(), TargetPos: dstDecl.Pos()},
+			Name:       dstDecl.Name.Name,
+			TargetKind: ast.Fun,
+			Note:       "replacement is not of func type",
+		}
+		return
+	}
+	for i, decl := range dst.Decls {
+		if decl == dstDecl {
+			dst.Decls[i] = &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{replacement}}
+			scope.Unbind(dst, dstDecl)
+			scope.Bind(dst, replacement)
+			ok = true
+			return
+		}
+	}
+	return
+}
+
+// VarToFunction : replace the var with the function converted to a var of func type (e.g. `var F = func() {}`)
+func VarToFunction(dst *ast.File, dstSpec *ast.ValueSpec, replacement *ast.FuncDecl) (ok bool, err error) {
+	if replacement == nil {
+		return
+	}
+	if len(dstSpec.Names) != 1 {
+		err = &failure.KindMismatchError{
+			Detail:     failure.Detail{Kind: ast.Fun, SourcePos: replacement.Pos(), TargetPos: dstSpec.Pos()},
+			Name:       replacement.Name.Name,
+			TargetKind: ast.Var,
+			Note:       "multiple names in var spec",
+		}
+		return
+	}
+	spec := &ast.ValueSpec{
+		Doc:    replacement.Doc,
+		Names:  []*ast.Ident{ast.NewIdent(replacement.Name.Name)},
+		Values: []ast.Expr{&ast.FuncLit{Type: replacement.Type, Body: replacement.Body}},
+	}
+	return SpecToFile(dst, dstSpec, spec)
+}
+
+// isFuncTyped : the var named name in spec is declared with func type, or initialized by func literal
+func isFuncTyped(spec *ast.ValueSpec, name string) bool {
+	if _, ok := spec.Type.(*ast.FuncType); ok {
+		return true
+	}
+	for i, ident := range spec.Names {
+		if ident.Name != name || i >= len(spec.Values) {
+			continue
+		}
+		_, ok := spec.Values[i].(*ast.FuncLit)
+		return ok
+	}
+	return false
+}
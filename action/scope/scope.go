@@ -0,0 +1,71 @@
+package scope
+
+import (
+	"go/ast"
+)
+
+// Bind : insert objects of the declaration into file scope (Object.Decl points to node)
+func Bind(f *ast.File, node ast.Node) {
+	if f.Scope == nil {
+		return
+	}
+	for _, ident := range declaredIdents(node) {
+		if ident.Name == "_" {
+			continue
+		}
+		ob := ident.Obj
+		if ob == nil {
+			ob = ast.NewObj(kindOf(node), ident.Name)
+			ob.Decl = node
+			ident.Obj = ob
+		}
+		f.Scope.Objects[ident.Name] = ob
+	}
+}
+
+// Unbind : remove objects of the declaration from file scope
+func Unbind(f *ast.File, node ast.Node) {
+	if f.Scope == nil {
+		return
+	}
+	for _, ident := range declaredIdents(node) {
+		if ob := f.Scope.Objects[ident.Name]; ob != nil && ob.Decl == node {
+			delete(f.Scope.Objects, ident.Name)
+		}
+	}
+}
+
+func declaredIdents(node ast.Node) []*ast.Ident {
+	switch t := node.(type) {
+	case *ast.FuncDecl:
+		if t.Recv != nil || t.Name.Name == "init" {
+			return nil
+		}
+		return []*ast.Ident{t.Name}
+	case *ast.TypeSpec:
+		return []*ast.Ident{t.Name}
+	case *ast.ValueSpec:
+		return t.Names
+	case *ast.GenDecl:
+		var idents []*ast.Ident
+		for _, spec := range t.Specs {
+			idents = append(idents, declaredIdents(spec)...)
+		}
+		return idents
+	default:
+		return nil
+	}
+}
+
+func kindOf(node ast.Node) ast.ObjKind {
+	switch node.(type) {
+	case *ast.FuncDecl:
+		return ast.Fun
+	case *ast.TypeSpec:
+		return ast.Typ
+	case *ast.ValueSpec:
+		return ast.Var
+	default:
+		return ast.Bad
+	}
+}
@@ -0,0 +1,64 @@
+package action
+
+import (
+	"go/ast"
+	"go/token"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action/failure"
+	"github.com/podhmo/astknife/action/wrap"
+	"github.com/podhmo/astknife/lookup"
+)
+
+// Wrap : wrap the function or method in f (r is its lookup result), by the body of template function (see wrap.Mode)
+func Wrap(k *lookup.Lookup, f *ast.File, r *lookup.Result, tmpl *lookup.Result, mode wrap.Mode, options ...func(*Config)) (ok bool, err error) {
+	c := newConfig(options)
+	defer c.resolve(&err, r)
+	if r == nil {
+		return false, ErrTargetNotFound
+	}
+	if tmpl == nil {
+		return false, ErrReplacementNotFound
+	}
+	tfn, can := tmpl.Node().(*ast.FuncDecl)
+	if !can {
+		return false, &failure.UnsupportedKindError{
+			Detail: failure.Detail{SourcePos: tmpl.Node().Pos()},
+			Name:   tmpl.FullName(),
+		}
+	}
+
+	var fn *ast.FuncDecl
+	switch r.Type {
+	case lookup.TypeToplevel:
+		drObject := f.Scope.Lookup(r.Name())
+		if drObject == nil {
+			return false, ErrTargetNotFound
+		}
+		if fn, can = drObject.Decl.(*ast.FuncDecl); !can {
+			return false, &failure.UnsupportedKindError{
+				Detail: failure.Detail{Kind: drObject.Kind, TargetPos: drObject.Pos()},
+				Name:   r.Name(),
+			}
+		}
+	case lookup.TypeMethod:
+		for _, decl := range f.Decls {
+			if decl, can := decl.(*ast.FuncDecl); can && lookup.IsMethod(decl) && decl.Name.Name == r.Name() && lookup.ReceiverName(decl) == lookup.ReceiverName(r.FuncDecl) {
+				fn = decl
+			}
+		}
+		if fn == nil {
+			return false, ErrTargetNotFound
+		}
+	default:
+		return false, errors.New("not implemented")
+	}
+	if err := c.checkProtected(f, r.FullName(), fn); err != nil {
+		return false, err
+	}
+	fset := c.Fset
+	if fset == nil {
+		fset = token.NewFileSet()
+	}
+	return wrap.FunctionToFile(fset, f, fn, tfn, mode)
+}
@@ -0,0 +1,176 @@
+package wrap
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action/failure"
+	"github.com/podhmo/astknife/action/replace"
+	"github.com/podhmo/astknife/action/scope"
+	"github.com/podhmo/astknife/clone"
+	"github.com/podhmo/astknife/lookup"
+	"github.com/podhmo/astknife/printer"
+)
+
+// Mode : how to wrap the function
+type Mode string
+
+const (
+	// ModePrologue : insert the body of template, at the beginning of the function
+	ModePrologue = Mode("prologue")
+	// ModeEpilogue : insert the body of template, as deferred function (`defer func() { ... }()`)
+	ModeEpilogue = Mode("epilogue")
+	// ModeInner : rename the function to xxxInner, and generate the wrapper with same signature (template is its prologue)
+	ModeInner = Mode("inner")
+)
+
+// InnerSuffix : suffix of the renamed function, in ModeInner
+const InnerSuffix = "Inner"
+
+// FunctionToFile : wrap the function (or method) in dst, by the body of template (imports used by template are not added)
+func FunctionToFile(fset *token.FileSet, dst *ast.File, fn *ast.FuncDecl, tmpl *ast.FuncDecl, mode Mode) (ok bool, err error) {
+	if fn == nil || tmpl == nil {
+		return
+	}
+	if fn.Body == nil || tmpl.Body == nil {
+		err = errors.Errorf("%s: function without body cannot be wrapped", fn.Name.Name)
+		return
+	}
+
+	switch mode {
+	case ModePrologue:
+		return replace.FunctionToFile(dst, fn, withBody(fn, append(statements(tmpl), fn.Body.List...)))
+	case ModeEpilogue:
+		deferred := &ast.DeferStmt{
+			Call: &ast.CallExpr{
+				Fun: &ast.FuncLit{
+					Type: &ast.FuncType{Params: &ast.FieldList{}},
+					Body: &ast.BlockStmt{List: statements(tmpl)},
+				},
+			},
+		}
+		return replace.FunctionToFile(dst, fn, withBody(fn, append([]ast.Stmt{deferred}, fn.Body.List...)))
+	case ModeInner:
+		return inner(fset, dst, fn, tmpl)
+	default:
+		err = errors.Errorf("unsupported mode %q", mode)
+		return
+	}
+}
+
+func inner(fset *token.FileSet, dst *ast.File, fn *ast.FuncDecl, tmpl *ast.FuncDecl) (ok bool, err error) {
+	name := fn.Name.Name + InnerSuffix
+	for _, decl := range dst.Decls {
+		if decl, can := decl.(*ast.FuncDecl); can && decl.Name.Name == name && lookup.ReceiverName(decl) == lookup.ReceiverName(fn) {
+			err = &failure.AlreadyExistsError{
+				Detail: failure.Detail{Kind: ast.Fun, SourcePos: fn.Pos(), TargetPos: decl.Pos()},
+				Name:   name,
+			}
+			return
+		}
+	}
+
+	// the original body is kept in place, and the wrapper is appended
+	renamed := *fn
+	renamed.Name = &ast.Ident{NamePos: fn.Name.NamePos, Name: name}
+
+	// wrapper, all parameters are named, to be forwarded
+	header := &ast.FuncDecl{
+		Recv: named(fn.Recv, "recv"),
+		Name: fn.Name,
+		Type: &ast.FuncType{Func: fn.Type.Func, Params: named(fn.Type.Params, "p"), Results: fn.Type.Results},
+	}
+	call := &ast.CallExpr{Fun: ast.NewIdent(name)}
+	if header.Recv != nil {
+		call.Fun = &ast.SelectorExpr{X: ast.NewIdent(header.Recv.List[0].Names[0].Name), Sel: ast.NewIdent(name)}
+	}
+	for _, field := range header.Type.Params.List {
+		for _, ident := range field.Names {
+			call.Args = append(call.Args, ast.NewIdent(ident.Name))
+		}
+		if _, variadic := field.Type.(*ast.Ellipsis); variadic {
+			call.Ellipsis = field.Type.Pos()
+		}
+	}
+	var last ast.Stmt = &ast.ExprStmt{X: call}
+	if fn.Type.Results != nil && len(fn.Type.Results.List) > 0 {
+		last = &ast.ReturnStmt{Results: []ast.Expr{call}}
+	}
+
+	// generated as source, and parsed
+	code, err := printer.SprintCode(fset, header)
+	if err != nil {
+		return false, err
+	}
+	var b strings.Builder
+	b.WriteString("package p\n\n" + code + " {\n")
+	for _, stmt := range append(append([]ast.Stmt(nil), tmpl.Body.List...), last) {
+		code, err := printer.SprintCode(fset, stmt)
+		if err != nil {
+			return false, err
+		}
+		b.WriteString(code + "\n")
+	}
+	b.WriteString("}\n")
+	f, err := parser.ParseFile(fset, "", b.String(), parser.ParseComments)
+	if err != nil {
+		return false, errors.Wrap(err, "generate wrapper")
+	}
+	generated := f.Decls[0].(*ast.FuncDecl)
+
+	if ok, err = replace.FunctionToFile(dst, fn, &renamed); !ok || err != nil {
+		return
+	}
+	dst.Decls = append(dst.Decls, generated)
+	scope.Bind(dst, generated)
+	return
+}
+
+// statements : copy of the statements of template
+func statements(tmpl *ast.FuncDecl) []ast.Stmt {
+	return clone.Node(tmpl.Body, clone.WithStripPositions()).(*ast.BlockStmt).List
+}
+
+func withBody(fn *ast.FuncDecl, stmts []ast.Stmt) *ast.FuncDecl {
+	replacement := *fn
+	replacement.Body = &ast.BlockStmt{Lbrace: fn.Body.Lbrace, List: stmts, Rbrace: fn.Body.Rbrace}
+	return &replacement
+}
+
+// named : copy of fields, whose unnamed (or "_") entries are named (e.g. p0, p1, or recv for receiver)
+func named(fields *ast.FieldList, prefix string) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+	copied := &ast.FieldList{Opening: fields.Opening, Closing: fields.Closing}
+	i := 0
+	for _, field := range fields.List {
+		newfield := *field
+		if len(field.Names) == 0 {
+			newfield.Names = []*ast.Ident{ast.NewIdent(nameOf(prefix, i))}
+			i++
+		} else {
+			newfield.Names = make([]*ast.Ident, len(field.Names))
+			for j, ident := range field.Names {
+				newfield.Names[j] = ident
+				if ident.Name == "_" {
+					newfield.Names[j] = ast.NewIdent(nameOf(prefix, i))
+				}
+				i++
+			}
+		}
+		copied.List = append(copied.List, &newfield)
+	}
+	return copied
+}
+
+func nameOf(prefix string, i int) string {
+	if prefix == "recv" {
+		return prefix
+	}
+	return fmt.Sprintf("%s%d", prefix, i)
+}
@@ -0,0 +1,150 @@
+package annotation
+
+import (
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"strings"
+
+	"github.com/podhmo/astknife/lookup"
+)
+
+// Prefix : prefix of magic comments
+const Prefix = "//astknife:"
+
+// Kind :
+type Kind string
+
+const (
+	// KindReplace : replace the declaration of destination
+	KindReplace = Kind("replace")
+	// KindAppend : append the declaration to destination
+	KindAppend = Kind("append")
+	// KindDelete : delete the named declaration from destination (e.g. `//astknife:delete Name`)
+	KindDelete = Kind("delete")
+	// KindKeep : keep the declaration as is (never touched)
+	KindKeep = Kind("keep")
+)
+
+// Directive :
+type Directive struct {
+	Kind Kind
+	Name string // lookup name (e.g. "S", "S.String")
+	Pos  token.Pos
+}
+
+// Parse : collect directives in file. unknown or conflicted directives are reported as scanner.ErrorList
+func Parse(fset *token.FileSet, file *ast.File) ([]*Directive, error) {
+	var errs scanner.ErrorList
+	var directives []*Directive
+	seen := map[string]*Directive{}
+
+	add := func(d *Directive) {
+		if prev, ok := seen[d.Name]; ok {
+			if prev.Kind != d.Kind {
+				errs.Add(fset.Position(d.Pos), "conflicting directives for "+d.Name+": "+string(prev.Kind)+" (at "+fset.Position(prev.Pos).String()+") and "+string(d.Kind))
+			}
+			return
+		}
+		seen[d.Name] = d
+		directives = append(directives, d)
+	}
+
+	attached := map[*ast.CommentGroup]bool{}
+	attach := func(doc *ast.CommentGroup, names []string) {
+		if doc == nil {
+			return
+		}
+		attached[doc] = true
+		for _, c := range doc.List {
+			kind, args, ok := parseLine(c.Text)
+			if !ok || kind == KindDelete {
+				continue // delete directives are collected below
+			}
+			switch kind {
+			case KindReplace, KindAppend, KindKeep:
+				if len(args) > 0 {
+					errs.Add(fset.Position(c.Pos()), "unexpected arguments for "+string(kind)+": "+strings.Join(args, " "))
+					continue
+				}
+				for _, name := range names {
+					add(&Directive{Kind: kind, Name: name, Pos: c.Pos()})
+				}
+			}
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			name := decl.Name.Name
+			if lookup.IsMethod(decl) {
+				name = lookup.ReceiverName(decl) + "." + name
+			}
+			attach(decl.Doc, []string{name})
+		case *ast.GenDecl:
+			var names []string
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, spec.Name.Name)
+					attach(spec.Doc, []string{spec.Name.Name})
+				case *ast.ValueSpec:
+					names = append(names, spec.Names[0].Name)
+					attach(spec.Doc, []string{spec.Names[0].Name})
+				}
+			}
+			attach(decl.Doc, names)
+		}
+	}
+
+	for _, cg := range file.Comments {
+		for _, c := range cg.List {
+			kind, args, ok := parseLine(c.Text)
+			if !ok {
+				continue
+			}
+			switch kind {
+			case KindDelete:
+				if len(args) != 1 {
+					errs.Add(fset.Position(c.Pos()), "delete directive requires exactly one name")
+					continue
+				}
+				add(&Directive{Kind: kind, Name: args[0], Pos: c.Pos()})
+			case KindReplace, KindAppend, KindKeep:
+				if !attached[cg] {
+					errs.Add(fset.Position(c.Pos()), string(kind)+" directive is not attached to any declaration")
+				}
+			default:
+				errs.Add(fset.Position(c.Pos()), "unknown directive "+strings.TrimPrefix(c.Text, "//"))
+			}
+		}
+	}
+
+	errs.Sort()
+	return directives, errs.Err()
+}
+
+// Has : doc comment has the directive or not
+func Has(doc *ast.CommentGroup, kind Kind) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if k, _, ok := parseLine(c.Text); ok && k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func parseLine(text string) (Kind, []string, bool) {
+	if !strings.HasPrefix(text, Prefix) {
+		return "", nil, false
+	}
+	fields := strings.Fields(strings.TrimPrefix(text, Prefix))
+	if len(fields) == 0 {
+		return "", nil, true
+	}
+	return Kind(fields[0]), fields[1:], true
+}
@@ -0,0 +1,104 @@
+package clone
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+)
+
+var (
+	posType    = reflect.TypeOf(token.NoPos)
+	objectType = reflect.TypeOf((*ast.Object)(nil))
+	scopeType  = reflect.TypeOf((*ast.Scope)(nil))
+)
+
+// Config :
+type Config struct {
+	StripPositions bool
+}
+
+// WithStripPositions : positions of the copy are cleared (e.g. for the code inserted into other place)
+func WithStripPositions() func(*Config) {
+	return func(c *Config) {
+		c.StripPositions = true
+	}
+}
+
+// Node : deep copy of node.
+// the objects declared inside of node are also copied (keeping the relations), the others are shared
+func Node(node ast.Node, options ...func(*Config)) ast.Node {
+	c := &cloner{Config: &Config{}, inside: map[interface{}]bool{}, memo: map[interface{}]reflect.Value{}}
+	for _, op := range options {
+		op(c.Config)
+	}
+	ast.Inspect(node, func(node ast.Node) bool {
+		if node != nil {
+			c.inside[node] = true
+		}
+		return true
+	})
+	return c.copy(reflect.ValueOf(node)).Interface().(ast.Node)
+}
+
+type cloner struct {
+	*Config
+	inside map[interface{}]bool
+	memo   map[interface{}]reflect.Value
+}
+
+func (c *cloner) copy(v reflect.Value) reflect.Value {
+	switch v.Type() {
+	case posType:
+		if c.StripPositions {
+			return reflect.ValueOf(token.NoPos)
+		}
+		return v
+	case scopeType:
+		return v
+	case objectType:
+		if ob := v.Interface().(*ast.Object); ob == nil || !c.inside[ob.Decl] {
+			return v
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		key := v.Interface()
+		if copied, ok := c.memo[key]; ok {
+			return copied
+		}
+		copied := reflect.New(v.Type().Elem())
+		c.memo[key] = copied
+		copied.Elem().Set(c.copy(v.Elem()))
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(c.copy(v.Elem()))
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if copied.Field(i).CanSet() {
+				copied.Field(i).Set(c.copy(v.Field(i)))
+			}
+		}
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(c.copy(v.Index(i)))
+		}
+		return copied
+	default:
+		return v
+	}
+}
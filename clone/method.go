@@ -0,0 +1,58 @@
+package clone
+
+import (
+	"go/ast"
+
+	"github.com/podhmo/astknife/lookup"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// Method : copy of the method, whose receiver type is rewritten (e.g. `func (s *S) M() *S` -> `func (s *S2) M() *S2`).
+// pointer or value receiver is kept, and references of the receiver type in signature and body are also rewritten.
+// ob is the object of new receiver type (can be nil)
+func Method(decl *ast.FuncDecl, ob *ast.Object, name string) *ast.FuncDecl {
+	from := lookup.ReceiverName(decl)
+	copied := Node(decl).(*ast.FuncDecl)
+	if from == "" {
+		return copied
+	}
+
+	declared := map[*ast.Object]bool{}
+	ast.Inspect(copied, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && ident.Obj != nil {
+			if field, ok := ident.Obj.Decl.(ast.Node); ok && field.Pos() >= decl.Pos() && field.End() <= decl.End() {
+				declared[ident.Obj] = true
+			}
+		}
+		return true
+	})
+
+	astutil.Apply(copied, func(c *astutil.Cursor) bool {
+		ident, ok := c.Node().(*ast.Ident)
+		if !ok || ident.Name != from {
+			return true
+		}
+		switch parent := c.Parent().(type) {
+		case *ast.SelectorExpr:
+			if parent.Sel == ident {
+				return true
+			}
+		case *ast.FuncDecl:
+			if parent.Name == ident {
+				return true
+			}
+		case *ast.KeyValueExpr: // field name of composite literal
+			if parent.Key == ident {
+				return true
+			}
+		}
+		// shadowed by the local declaration
+		if ident.Obj != nil && (declared[ident.Obj] || ident.Obj.Kind != ast.Typ) {
+			return true
+		}
+		ident.Name = name
+		ident.Obj = ob
+		return true
+	}, nil)
+	return copied
+}
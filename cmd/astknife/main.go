@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+
+	"github.com/podhmo/astknife/diff"
+	"github.com/podhmo/astknife/journal"
+	"github.com/podhmo/astknife/patchwork"
+)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "diff":
+		err = runDiff(os.Args[2:])
+	case "undo":
+		err = runUndo(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatalf("!! %+v", err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: astknife <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  diff [-json] <old.go> <new.go>")
+	fmt.Fprintln(os.Stderr, "  undo -journal <journal.json> <file.go>...")
+	os.Exit(2)
+}
+
+func runDiff(args []string) error {
+	cmd := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := cmd.Bool("json", false, "output as JSON")
+	cmd.Parse(args)
+	if cmd.NArg() != 2 {
+		usage()
+	}
+
+	fset := token.NewFileSet()
+	old, err := parser.ParseFile(fset, cmd.Arg(0), nil, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+	updated, err := parser.ParseFile(fset, cmd.Arg(1), nil, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	changes := diff.Diff(fset, old, updated)
+	if *asJSON {
+		return diff.FprintJSON(os.Stdout, changes)
+	}
+	return diff.Fprint(os.Stdout, changes)
+}
+
+func runUndo(args []string) error {
+	cmd := flag.NewFlagSet("undo", flag.ExitOnError)
+	journalFile := cmd.String("journal", "", "journal file")
+	cmd.Parse(args)
+	if cmd.NArg() == 0 || *journalFile == "" {
+		usage()
+	}
+
+	j, err := journal.Load(*journalFile)
+	if err != nil {
+		return err
+	}
+
+	// all files are reverted, before writing any of them
+	pw := patchwork.NewPatchwork()
+	var files []*patchwork.File
+	for _, filename := range cmd.Args() {
+		pf, err := pw.ParseFile(filename, nil)
+		if err != nil {
+			return err
+		}
+		if err := pf.Undo(j); err != nil {
+			return err
+		}
+		files = append(files, pf)
+	}
+	for i, pf := range files {
+		if err := pf.WriteFile(cmd.Arg(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package deps
+
+import (
+	"go/ast"
+	"strconv"
+
+	"github.com/podhmo/astknife/bypos"
+	"github.com/podhmo/astknife/clone"
+	"github.com/podhmo/astknife/lookup"
+)
+
+// Config :
+type Config struct {
+	// Skip : if returns true, the dependency is not included, and its dependencies are not walked (e.g. already defined in destination)
+	Skip func(r *lookup.Result) bool
+	// AllMethods : if true, all methods of collected types are included (otherwise, only the methods referred by name, e.g. `x.String()` or `interface{ String() string }`)
+	AllMethods bool
+}
+
+// WithSkip :
+func WithSkip(skip func(r *lookup.Result) bool) func(*Config) {
+	return func(c *Config) {
+		c.Skip = skip
+	}
+}
+
+// WithAllMethods : include all methods of collected types (e.g. to satisfy the interfaces of other packages, such as fmt.Stringer)
+func WithAllMethods() func(*Config) {
+	return func(c *Config) {
+		c.AllMethods = true
+	}
+}
+
+// Import : import spec, required by the collected declarations
+type Import struct {
+	Name string // empty, if not named
+	Path string
+}
+
+// Closure : the declaration r and its transitive dependencies (toplevel declarations found in k, and the methods of collected types referred by name).
+// r is always the first one, and the imports referred by them are also returned
+func Closure(k *lookup.Lookup, r *lookup.Result, options ...func(*Config)) ([]*lookup.Result, []*Import) {
+	c := &Config{}
+	for _, op := range options {
+		op(c)
+	}
+
+	files := bypos.SortFiles(append([]*ast.File(nil), k.Files...))
+	seen := map[ast.Node]bool{}
+	used := map[string]bool{}                // method names referred by collected declarations
+	pending := map[string][]*lookup.Result{} // methods of collected types, not referred yet
+	imported := map[Import]bool{}
+	var results []*lookup.Result
+	var imports []*Import
+
+	queue := []*lookup.Result{r}
+	for len(queue) > 0 {
+		r := queue[0]
+		queue = queue[1:]
+		node := r.Node()
+		if node == nil || seen[node] {
+			continue
+		}
+		seen[node] = true
+		for _, name := range methodNames(node) {
+			if !used[name] {
+				used[name] = true
+				queue = append(queue, pending[name]...)
+				delete(pending, name)
+			}
+		}
+		if len(results) > 0 && c.Skip != nil && c.Skip(r) {
+			continue
+		}
+		results = append(results, r)
+
+		if r.Type == lookup.TypeToplevel && r.Object.Kind == ast.Typ {
+			for _, method := range k.AllMethods(r.Name()) {
+				if c.AllMethods || used[method.Name()] {
+					queue = append(queue, method)
+				} else {
+					pending[method.Name()] = append(pending[method.Name()], method)
+				}
+			}
+		}
+
+		file := bypos.FindFile(files, node.Pos())
+		for _, ident := range References(node) {
+			if dep := k.Toplevel(ident.Name); dep != nil {
+				queue = append(queue, dep)
+			}
+		}
+		for _, im := range ImportsOf(file, node) {
+			if !imported[*im] {
+				imported[*im] = true
+				imports = append(imports, im)
+			}
+		}
+	}
+	return results, imports
+}
+
+// methodNames : names which may refer methods in node (selectors, and methods of interface types)
+func methodNames(node ast.Node) []string {
+	var names []string
+	ast.Inspect(node, func(node ast.Node) bool {
+		switch t := node.(type) {
+		case *ast.SelectorExpr:
+			names = append(names, t.Sel.Name)
+		case *ast.InterfaceType:
+			for _, field := range t.Methods.List {
+				for _, name := range field.Names {
+					names = append(names, name.Name)
+				}
+			}
+		}
+		return true
+	})
+	return names
+}
+
+// References : identifiers which may refer the toplevel declarations (declared names, local ones, selectors and labels are excluded)
+func References(node ast.Node) []*ast.Ident {
+	ignored := map[*ast.Ident]bool{}
+	ignore := func(idents ...*ast.Ident) {
+		for _, ident := range idents {
+			ignored[ident] = true
+		}
+	}
+
+	var refs []*ast.Ident
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch t := n.(type) {
+		case *ast.SelectorExpr:
+			ignore(t.Sel)
+		case *ast.Field:
+			ignore(t.Names...)
+		case *ast.FuncDecl:
+			ignore(t.Name)
+		case *ast.TypeSpec:
+			ignore(t.Name)
+		case *ast.ValueSpec:
+			ignore(t.Names...)
+		case *ast.LabeledStmt:
+			ignore(t.Label)
+		case *ast.BranchStmt:
+			ignore(t.Label)
+		case *ast.KeyValueExpr:
+			// field name of struct literal is not resolved
+			if key, ok := t.Key.(*ast.Ident); ok && key.Obj == nil {
+				ignore(key)
+			}
+		case *ast.Ident:
+			if ignored[t] || t.Name == "_" {
+				return true
+			}
+			if t.Obj != nil {
+				if decl, ok := t.Obj.Decl.(ast.Node); ok && decl != node && node.Pos() <= decl.Pos() && decl.End() <= node.End() {
+					return true // local
+				}
+			}
+			refs = append(refs, t)
+		}
+		return true
+	})
+	return refs
+}
+
+// ImportsOf : imports of file, referred in node (e.g. `strings.Join`)
+func ImportsOf(file *ast.File, node ast.Node) []*Import {
+	if file == nil {
+		return nil
+	}
+	var imports []*Import
+	ast.Inspect(node, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		x, ok := sel.X.(*ast.Ident)
+		if !ok || x.Obj != nil {
+			return true
+		}
+		for _, spec := range file.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			if spec.Name != nil {
+				if spec.Name.Name == x.Name {
+					imports = append(imports, &Import{Name: spec.Name.Name, Path: path})
+				}
+				continue
+			}
+			if path == x.Name || (len(path) > len(x.Name) && path[len(path)-len(x.Name)-1:] == "/"+x.Name) {
+				imports = append(imports, &Import{Path: path})
+			}
+		}
+		return true
+	})
+	return imports
+}
+
+// Rename : copies of results, whose declared names and references are renamed (e.g. {"helper": "helperCopied"}).
+// the results not touched are returned as is
+func Rename(results []*lookup.Result, renames map[string]string) []*lookup.Result {
+	renamed := make([]*lookup.Result, len(results))
+	for i, r := range results {
+		node := r.Node()
+		touched := false
+		for _, ident := range References(node) {
+			if _, ok := renames[ident.Name]; ok {
+				touched = true
+			}
+		}
+		if _, ok := renames[r.Name()]; ok && r.Type == lookup.TypeToplevel {
+			touched = true
+		}
+		if !touched {
+			renamed[i] = r
+			continue
+		}
+
+		copied := clone.Node(node)
+		for _, ident := range References(copied) {
+			if name, ok := renames[ident.Name]; ok {
+				ident.Name = name
+			}
+		}
+		switch r.Type {
+		case lookup.TypeMethod:
+			renamed[i] = &lookup.Result{Type: r.Type, Object: r.Object, FuncDecl: copied.(*ast.FuncDecl), Fset: r.Fset}
+		case lookup.TypeToplevel:
+			ob := &ast.Object{Kind: r.Object.Kind, Name: r.Object.Name, Decl: copied, Data: r.Object.Data}
+			if name, ok := renames[ob.Name]; ok {
+				ob.Name = name
+			}
+			for _, ident := range declaredNames(copied) {
+				if ident.Name == r.Object.Name {
+					ident.Name = ob.Name
+					ident.Obj = ob
+				}
+			}
+			renamed[i] = &lookup.Result{Type: r.Type, Object: ob, Fset: r.Fset}
+		}
+	}
+	return renamed
+}
+
+func declaredNames(node ast.Node) []*ast.Ident {
+	switch t := node.(type) {
+	case *ast.FuncDecl:
+		return []*ast.Ident{t.Name}
+	case *ast.TypeSpec:
+		return []*ast.Ident{t.Name}
+	case *ast.ValueSpec:
+		return t.Names
+	}
+	return nil
+}
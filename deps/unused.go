@@ -0,0 +1,130 @@
+package deps
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/podhmo/astknife/lookup"
+)
+
+// Unreferenced : unexported toplevel declarations in k (and the methods of such types), not reachable from the roots.
+// the roots are exported declarations, `main`, `init`, blank declarations (e.g. `var _ I = &T{}`), vars initialized with calls (e.g. `var x = register()`),
+// all declarations in _test.go files (fset is used to find them, if nil, no files are treated as test files),
+// and the methods of types defined in other files. the methods of reachable types are always reachable (e.g. to satisfy interfaces)
+func Unreferenced(fset *token.FileSet, k *lookup.Lookup) []*lookup.Result {
+	reachable := map[ast.Node]bool{}
+	var queue []ast.Node
+	for _, f := range k.Files {
+		if fset != nil && strings.HasSuffix(fset.Position(f.Package).Filename, "_test.go") {
+			queue = append(queue, declarations(f)...)
+			continue
+		}
+		queue = append(queue, roots(k, f)...)
+	}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if node == nil || reachable[node] {
+			continue
+		}
+		reachable[node] = true
+
+		if spec, ok := node.(*ast.TypeSpec); ok {
+			for _, r := range k.AllMethods(spec.Name.Name) {
+				queue = append(queue, r.Node())
+			}
+		}
+		for _, ident := range References(node) {
+			if dep := k.Toplevel(ident.Name); dep != nil {
+				queue = append(queue, dep.Node())
+			}
+		}
+	}
+
+	var unreferenced []*lookup.Result
+	for _, r := range k.All() {
+		if reachable[r.Node()] {
+			continue
+		}
+		if r.Type == lookup.TypeMethod && r.Object.Decl == nil {
+			continue // receiver type is defined in other files
+		}
+		unreferenced = append(unreferenced, r)
+	}
+	return unreferenced
+}
+
+// roots : declarations in f, reachable by themselves
+func roots(k *lookup.Lookup, f *ast.File) []ast.Node {
+	var nodes []ast.Node
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if lookup.IsMethod(decl) {
+				if k.Toplevel(lookup.ReceiverName(decl)) == nil {
+					nodes = append(nodes, decl)
+				}
+				continue
+			}
+			if name := decl.Name.Name; ast.IsExported(name) || name == "main" || name == "init" {
+				nodes = append(nodes, decl)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if ast.IsExported(spec.Name.Name) {
+						nodes = append(nodes, spec)
+					}
+				case *ast.ValueSpec:
+					if hasCall(spec) {
+						nodes = append(nodes, spec)
+						continue
+					}
+					for _, name := range spec.Names {
+						if ast.IsExported(name.Name) || name.Name == "_" {
+							nodes = append(nodes, spec)
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+	return nodes
+}
+
+// declarations : all toplevel declarations in f
+func declarations(f *ast.File) []ast.Node {
+	var nodes []ast.Node
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			nodes = append(nodes, decl)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				nodes = append(nodes, spec)
+			}
+		}
+	}
+	return nodes
+}
+
+// hasCall : the initializer of spec calls something at initialization (calls in func literals are not counted)
+func hasCall(spec *ast.ValueSpec) bool {
+	found := false
+	for _, value := range spec.Values {
+		ast.Inspect(value, func(node ast.Node) bool {
+			switch node.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.CallExpr:
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
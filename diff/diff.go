@@ -0,0 +1,172 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+
+	"github.com/podhmo/astknife/equal"
+	"github.com/podhmo/astknife/lookup"
+)
+
+// Kind : kind of change
+type Kind string
+
+const (
+	// KindAdded : only in new file
+	KindAdded = Kind("added")
+	// KindRemoved : only in old file
+	KindRemoved = Kind("removed")
+	// KindModified : the declaration is changed
+	KindModified = Kind("modified")
+	// KindCommentOnly : only comments are changed
+	KindCommentOnly = Kind("comment-only")
+)
+
+// Change :
+type Change struct {
+	Kind Kind   `json:"kind"`
+	Name string `json:"name"` // e.g. "S", "S.String"
+
+	Old *lookup.Result `json:"-"` // nil if added
+	New *lookup.Result `json:"-"` // nil if removed
+
+	OldPosition *token.Position `json:"oldPosition,omitempty"`
+	NewPosition *token.Position `json:"newPosition,omitempty"`
+}
+
+// Diff : structural diff at the declaration level. whitespace-only changes are ignored.
+func Diff(fset *token.FileSet, old, updated *ast.File) []*Change {
+	docs := genDeclDocs(old, updated)
+	olds := map[string]*lookup.Result{}
+	for _, r := range lookup.New(old).All() {
+		olds[r.FullName()] = r
+	}
+
+	var changes []*Change
+	seen := map[string]bool{}
+	for _, r := range lookup.New(updated).All() {
+		name := r.FullName()
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		o, ok := olds[name]
+		switch {
+		case !ok:
+			changes = append(changes, &Change{Kind: KindAdded, Name: name, New: r})
+		case !equal.Node(o.Node(), r.Node(), equal.WithIgnoreComments()):
+			changes = append(changes, &Change{Kind: KindModified, Name: name, Old: o, New: r})
+		case !sameStrings(commentsOf(old, docs, o.Node()), commentsOf(updated, docs, r.Node())):
+			changes = append(changes, &Change{Kind: KindCommentOnly, Name: name, Old: o, New: r})
+		}
+	}
+	for _, r := range lookup.New(old).All() {
+		name := r.FullName()
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		changes = append(changes, &Change{Kind: KindRemoved, Name: name, Old: r})
+	}
+
+	for _, c := range changes {
+		if c.Old != nil {
+			pos := fset.Position(c.Old.Node().Pos())
+			c.OldPosition = &pos
+		}
+		if c.New != nil {
+			pos := fset.Position(c.New.Node().Pos())
+			c.NewPosition = &pos
+		}
+	}
+	return changes
+}
+
+// Fprint : print changes as text (e.g. `modified S.String (a.go:10:1 -> b.go:12:1)`)
+func Fprint(w io.Writer, changes []*Change) error {
+	for _, c := range changes {
+		var err error
+		switch {
+		case c.OldPosition == nil:
+			_, err = fmt.Fprintf(w, "%s %s (%s)\n", c.Kind, c.Name, c.NewPosition)
+		case c.NewPosition == nil:
+			_, err = fmt.Fprintf(w, "%s %s (%s)\n", c.Kind, c.Name, c.OldPosition)
+		default:
+			_, err = fmt.Fprintf(w, "%s %s (%s -> %s)\n", c.Kind, c.Name, c.OldPosition, c.NewPosition)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FprintJSON : print changes as JSON
+func FprintJSON(w io.Writer, changes []*Change) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(changes)
+}
+
+// genDeclDocs : doc comments of GenDecl (e.g. `// S : ...` of `type S struct{}`) are not a part of spec
+func genDeclDocs(files ...*ast.File) map[ast.Node]*ast.CommentGroup {
+	docs := map[ast.Node]*ast.CommentGroup{}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			if decl, ok := decl.(*ast.GenDecl); ok && decl.Doc != nil {
+				for _, spec := range decl.Specs {
+					docs[spec] = decl.Doc
+				}
+			}
+		}
+	}
+	return docs
+}
+
+// commentsOf : raw text of the comments of node, including directives (e.g. `//go:noinline`) and the comments in function body
+func commentsOf(f *ast.File, docs map[ast.Node]*ast.CommentGroup, node ast.Node) []string {
+	start, end := node.Pos(), node.End()
+	var doc, comment *ast.CommentGroup
+	switch t := node.(type) {
+	case *ast.FuncDecl:
+		doc = t.Doc
+	case *ast.TypeSpec:
+		doc, comment = t.Doc, t.Comment
+	case *ast.ValueSpec:
+		doc, comment = t.Doc, t.Comment
+	}
+	for _, cg := range []*ast.CommentGroup{doc, docs[node]} {
+		if cg != nil && cg.Pos() < start {
+			start = cg.Pos()
+		}
+	}
+	if comment != nil && comment.End() > end {
+		end = comment.End()
+	}
+
+	var texts []string
+	for _, cg := range f.Comments {
+		if start <= cg.Pos() && cg.End() <= end {
+			for _, c := range cg.List {
+				texts = append(texts, c.Text)
+			}
+		}
+	}
+	return texts
+}
+
+func sameStrings(xs, ys []string) bool {
+	if len(xs) != len(ys) {
+		return false
+	}
+	for i := range xs {
+		if xs[i] != ys[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package diff
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := `
+package p
+
+// S : struct
+type S struct {
+	Name string
+}
+
+func (s *S) String() string {
+	return s.Name
+}
+
+func Hello() string { return "hello" }
+
+func Removed() {}
+
+func Body() int {
+	// answer
+	return 42
+}
+
+//go:noinline
+func Directive() {}
+`
+	updated := `
+package p
+
+// S : *modified comment*
+type S struct {
+	Name string
+}
+
+func (s *S) String() string {
+	return "s"
+}
+
+func Hello() string {
+	return "hello"
+}
+
+func Added() {}
+
+func Body() int {
+	// *modified comment in body*
+	return 42
+}
+
+func Directive() {}
+`
+	fset := token.NewFileSet()
+	parse := func(name, source string) *ast.File {
+		f, err := parser.ParseFile(fset, name, source, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	changes := Diff(fset, parse("old", old), parse("new", updated))
+	expected := map[string]Kind{
+		"S":         KindCommentOnly,
+		"S.String":  KindModified,
+		"Added":     KindAdded,
+		"Removed":   KindRemoved,
+		"Body":      KindCommentOnly,
+		"Directive": KindCommentOnly,
+	}
+	if len(changes) != len(expected) {
+		t.Errorf("expected %d changes, but got %d", len(expected), len(changes))
+	}
+	for _, c := range changes {
+		t.Logf("%s %s", c.Kind, c.Name)
+		if expected[c.Name] != c.Kind {
+			t.Errorf("%s: expected kind is %q, but got %q", c.Name, expected[c.Name], c.Kind)
+		}
+	}
+}
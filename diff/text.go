@@ -0,0 +1,53 @@
+package diff
+
+import (
+	"strings"
+)
+
+// Lines : line based diff (lines are prefixed with "-", "+" or " ")
+func Lines(old, updated string) string {
+	xs := splitLines(old)
+	ys := splitLines(updated)
+
+	// lcs[i][j] : length of LCS of xs[i:] and ys[j:]
+	lcs := make([][]int, len(xs)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(ys)+1)
+	}
+	for i := len(xs) - 1; i >= 0; i-- {
+		for j := len(ys) - 1; j >= 0; j-- {
+			if xs[i] == ys[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var b strings.Builder
+	i, j := 0, 0
+	for i < len(xs) || j < len(ys) {
+		switch {
+		case i < len(xs) && j < len(ys) && xs[i] == ys[j]:
+			b.WriteString(" " + xs[i] + "\n")
+			i++
+			j++
+		case i < len(xs) && (j == len(ys) || lcs[i+1][j] >= lcs[i][j+1]):
+			b.WriteString("-" + xs[i] + "\n")
+			i++
+		default:
+			b.WriteString("+" + ys[j] + "\n")
+			j++
+		}
+	}
+	return b.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
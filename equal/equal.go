@@ -0,0 +1,102 @@
+package equal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"reflect"
+)
+
+var (
+	posType          = reflect.TypeOf(token.NoPos)
+	objectType       = reflect.TypeOf((*ast.Object)(nil))
+	scopeType        = reflect.TypeOf((*ast.Scope)(nil))
+	commentGroupType = reflect.TypeOf((*ast.CommentGroup)(nil))
+)
+
+// Config :
+type Config struct {
+	IgnoreComments bool
+}
+
+// WithIgnoreComments :
+func WithIgnoreComments() func(*Config) {
+	return func(c *Config) {
+		c.IgnoreComments = true
+	}
+}
+
+// Node : position-insensitive equality of ast nodes (comments are compared by text)
+func Node(x, y ast.Node, options ...func(*Config)) bool {
+	var bx, by bytes.Buffer
+	Walk(&bx, x, options...)
+	Walk(&by, y, options...)
+	return bytes.Equal(bx.Bytes(), by.Bytes())
+}
+
+// Hash : position-insensitive hash of node, consistent with Node (empty if node is nil)
+func Hash(node ast.Node, options ...func(*Config)) string {
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return ""
+	}
+	h := sha256.New()
+	Walk(h, node, options...)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Walk : write the canonical form of node to w. positions, objects and scopes are skipped, and comments are written by text.
+// two nodes are equal iff their canonical forms are same
+func Walk(w io.Writer, node ast.Node, options ...func(*Config)) {
+	c := &Config{}
+	for _, op := range options {
+		op(c)
+	}
+	c.write(w, reflect.ValueOf(node))
+}
+
+func (c *Config) write(w io.Writer, v reflect.Value) {
+	if !v.IsValid() {
+		io.WriteString(w, "<invalid>")
+		return
+	}
+	switch v.Type() {
+	case posType, objectType, scopeType:
+		return
+	case commentGroupType:
+		if c.IgnoreComments {
+			return
+		}
+		fmt.Fprintf(w, "comment(%q)", v.Interface().(*ast.CommentGroup).Text())
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			io.WriteString(w, "nil")
+			return
+		}
+		fmt.Fprintf(w, "%s:", v.Elem().Type())
+		c.write(w, v.Elem())
+	case reflect.Struct:
+		io.WriteString(w, "{")
+		for i := 0; i < v.NumField(); i++ {
+			c.write(w, v.Field(i))
+			io.WriteString(w, ",")
+		}
+		io.WriteString(w, "}")
+	case reflect.Slice:
+		fmt.Fprintf(w, "[%d:", v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.write(w, v.Index(i))
+			io.WriteString(w, ",")
+		}
+		io.WriteString(w, "]")
+	default:
+		fmt.Fprintf(w, "%#v", v.Interface())
+	}
+}
@@ -0,0 +1,93 @@
+package fingerprint
+
+import (
+	"encoding/json"
+	"go/ast"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/equal"
+)
+
+// Status :
+type Status string
+
+const (
+	// StatusNew : never applied, or the patch is updated
+	StatusNew = Status("new")
+	// StatusApplied : already applied, and not modified since applied
+	StatusApplied = Status("applied")
+	// StatusDrifted : applied, but modified since applied
+	StatusDrifted = Status("drifted")
+)
+
+// Manifest : sidecar manifest, hash of each applied declaration
+type Manifest struct {
+	Entries map[string]string `json:"entries"` // name (e.g. "S", "S.String") -> hash
+}
+
+// NewManifest :
+func NewManifest() *Manifest {
+	return &Manifest{Entries: map[string]string{}}
+}
+
+// SidecarPath : default path of manifest (e.g. "foo.go" -> "foo.go.fingerprint.json")
+func SidecarPath(filename string) string {
+	return filename + ".fingerprint.json"
+}
+
+// Load : load manifest, if not existed, returns empty manifest
+func Load(filename string) (*Manifest, error) {
+	fp, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return NewManifest(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	m := NewManifest()
+	if err := json.NewDecoder(fp).Decode(m); err != nil {
+		return nil, errors.Wrapf(err, "load manifest %s", filename)
+	}
+	return m, nil
+}
+
+// Save :
+func (m *Manifest) Save(filename string) error {
+	fp, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+
+	encoder := json.NewEncoder(fp)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(m)
+}
+
+// Status : current is the declaration in destination (nil if not existed), replacement is the declaration to be applied
+func (m *Manifest) Status(name string, current ast.Node, replacement ast.Node) Status {
+	recorded, ok := m.Entries[name]
+	if !ok {
+		return StatusNew
+	}
+	if Of(current) != recorded {
+		return StatusDrifted
+	}
+	if Of(replacement) != recorded {
+		return StatusNew
+	}
+	return StatusApplied
+}
+
+// Record :
+func (m *Manifest) Record(name string, applied ast.Node) {
+	m.Entries[name] = Of(applied)
+}
+
+// Of : position-insensitive hash of node (consistent with equal.Node)
+func Of(node ast.Node) string {
+	return equal.Hash(node)
+}
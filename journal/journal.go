@@ -0,0 +1,97 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/lookup"
+	"github.com/podhmo/astknife/printer"
+)
+
+// Entry : before and after source text of the touched declaration
+type Entry struct {
+	Op     string `json:"op"`             // e.g. "append", "replace", "delete"
+	Name   string `json:"name"`           // e.g. "S", "S.String"
+	File   string `json:"file,omitempty"` // filename of the touched file
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// Journal :
+type Journal struct {
+	Entries []*Entry `json:"entries"`
+}
+
+// Load :
+func Load(filename string) (*Journal, error) {
+	fp, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	var j Journal
+	if err := json.NewDecoder(fp).Decode(&j); err != nil {
+		return nil, errors.Wrapf(err, "load journal %s", filename)
+	}
+	return &j, nil
+}
+
+// Save :
+func (j *Journal) Save(filename string) error {
+	fp, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+
+	encoder := json.NewEncoder(fp)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(j)
+}
+
+// Recorder : returns the function recording the entry. the before text is captured when Recorder is called.
+func (j *Journal) Recorder(fset *token.FileSet, f *ast.File, op string, name string) func() error {
+	before, err := Source(fset, f, name)
+	return func() error {
+		if err != nil {
+			return err
+		}
+		after, err := Source(fset, f, name)
+		if err != nil {
+			return err
+		}
+		if before != after {
+			j.Entries = append(j.Entries, &Entry{Op: op, Name: name, File: filename(fset, f), Before: before, After: after})
+		}
+		return nil
+	}
+}
+
+// Source : source text of the declaration named name in f (spec is printed with its keyword, e.g. `type S struct{}`)
+func Source(fset *token.FileSet, f *ast.File, name string) (string, error) {
+	node, tok := lookup.FindInFile(f, name)
+	if node == nil {
+		return "", nil
+	}
+	s, err := printer.SprintCode(fset, node)
+	if err != nil {
+		return "", err
+	}
+	if tok != token.ILLEGAL {
+		s = fmt.Sprintf("%s %s", tok, s)
+	}
+	return s, nil
+}
+
+func filename(fset *token.FileSet, f *ast.File) string {
+	if name := fset.Position(f.Package).Filename; name != "" {
+		return filepath.Clean(name)
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package journal
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/podhmo/astknife/action/replace"
+	"github.com/podhmo/astknife/equal"
+	"github.com/podhmo/astknife/printer"
+)
+
+func TestUndo(t *testing.T) {
+	source := `
+package p
+
+type S struct{}
+
+func (s *S) String() string {
+	return "s"
+}
+
+func Hello() string {
+	return "hello"
+}
+`
+	source2 := `
+package p
+
+func Hello() string {
+	return "*replaced*"
+}
+`
+	fset := token.NewFileSet()
+	parse := func(name, source string) *ast.File {
+		f, err := parser.ParseFile(fset, name, source, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	print := func(f *ast.File) string {
+		s, err := printer.SprintCode(fset, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	t.Run("undo", func(t *testing.T) {
+		f := parse("f0", source)
+		f2 := parse("f1", source2)
+		before := print(f)
+
+		j := &Journal{}
+		record := j.Recorder(fset, f, "replace", "Hello")
+		if _, err := replace.ToplevelToFile(f, f.Scope.Lookup("Hello"), f2.Scope.Lookup("Hello")); err != nil {
+			t.Fatal(err)
+		}
+		if err := record(); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(print(f), "*replaced*") {
+			t.Fatal("must be replaced")
+		}
+
+		if err := Undo(fset, f, j); err != nil {
+			t.Fatal(err)
+		}
+		// layout may be different, so compared structurally
+		if !equal.Node(parse("f0", source), f) {
+			t.Errorf("must be reverted\nbefore:\n%s\nafter:\n%s", before, print(f))
+		}
+	})
+
+	t.Run("modified since journaled", func(t *testing.T) {
+		f := parse("f0", source)
+		f2 := parse("f1", source2)
+
+		j := &Journal{Entries: []*Entry{{Op: "replace", Name: "Hello", Before: "func Hello() string {\n\treturn \"hello\"\n}", After: "func Hello() string {\n\treturn \"other\"\n}"}}}
+		if _, err := replace.ToplevelToFile(f, f.Scope.Lookup("Hello"), f2.Scope.Lookup("Hello")); err != nil {
+			t.Fatal(err)
+		}
+
+		err := Undo(fset, f, j)
+		if _, ok := err.(*ModifiedError); !ok {
+			t.Fatalf("ModifiedError is expected, but got %v", err)
+		}
+	})
+}
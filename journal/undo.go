@@ -0,0 +1,122 @@
+package journal
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action/delete"
+	"github.com/podhmo/astknife/action/replace"
+	"github.com/podhmo/astknife/lookup"
+)
+
+// ModifiedError : the journaled declaration has been modified since journaled
+type ModifiedError struct {
+	Entry *Entry
+}
+
+func (e *ModifiedError) Error() string {
+	return fmt.Sprintf("cannot undo %s %s, it has been modified since journaled", e.Entry.Op, e.Entry.Name)
+}
+
+// Undo : revert the journaled changes of f (in reverse order). f is modified in place.
+// the entries of other files (e.g. touched by WithReplaceDuplicates) are skipped, so undo each file of the package
+func Undo(fset *token.FileSet, f *ast.File, j *Journal) error {
+	name := filename(fset, f)
+	for i := len(j.Entries) - 1; i >= 0; i-- {
+		e := j.Entries[i]
+		if e.File != "" && name != "" && e.File != name {
+			continue
+		}
+		current, err := Source(fset, f, e.Name)
+		if err != nil {
+			return err
+		}
+		if current != e.After {
+			return &ModifiedError{Entry: e}
+		}
+		if err := revert(fset, f, e); err != nil {
+			return errors.Wrapf(err, "undo %s %s", e.Op, e.Name)
+		}
+	}
+	return nil
+}
+
+func revert(fset *token.FileSet, f *ast.File, e *Entry) error {
+	var decl ast.Decl
+	if e.Before != "" {
+		parsed, err := parser.ParseFile(fset, "", "package p\n"+e.Before, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		decl = parsed.Decls[0]
+		for name, ob := range parsed.Scope.Objects {
+			f.Scope.Objects[name] = ob
+		}
+	}
+
+	node, _ := lookup.FindInFile(f, e.Name)
+	switch {
+	case node == nil:
+		// deleted -> restore
+		f.Decls = append(f.Decls, decl)
+		return nil
+	case decl == nil:
+		// appended -> delete
+		if ob := f.Scope.Lookup(e.Name); ob != nil && ob.Decl == node {
+			_, err := delete.ToplevelToFile(f, ob)
+			return err
+		}
+		return remove(f, node)
+	}
+
+	if spec, ok := node.(ast.Spec); ok {
+		if gendecl, ok := decl.(*ast.GenDecl); ok && len(gendecl.Specs) == 1 {
+			_, err := replace.SpecToFile(f, spec, gendecl.Specs[0])
+			return err
+		}
+	}
+	// e.g. func <-> var, the whole declaration is replaced
+	for i := range f.Decls {
+		if f.Decls[i] == node || containsSpec(f.Decls[i], node) {
+			if gendecl, ok := f.Decls[i].(*ast.GenDecl); ok && len(gendecl.Specs) > 1 {
+				if err := remove(f, node); err != nil {
+					return err
+				}
+				f.Decls = append(f.Decls, decl)
+				return nil
+			}
+			f.Decls[i] = decl
+			return nil
+		}
+	}
+	return nil
+}
+
+func remove(f *ast.File, node ast.Node) error {
+	switch node := node.(type) {
+	case *ast.FuncDecl:
+		_, err := delete.FunctionToFile(f, node)
+		return err
+	case ast.Spec:
+		_, err := delete.SpecToFile(f, node)
+		return err
+	default:
+		return errors.Errorf("unexpected node %T", node)
+	}
+}
+
+func containsSpec(decl ast.Decl, node ast.Node) bool {
+	gendecl, ok := decl.(*ast.GenDecl)
+	if !ok {
+		return false
+	}
+	for _, spec := range gendecl.Specs {
+		if spec == node {
+			return true
+		}
+	}
+	return false
+}
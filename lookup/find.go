@@ -0,0 +1,44 @@
+package lookup
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// FindInFile : find the declaration by scanning f.Decls (f.Scope is not used, because it can be stale)
+// returned node is *ast.FuncDecl or ast.Spec (with the token of its GenDecl)
+func FindInFile(f *ast.File, name string) (ast.Node, token.Token) {
+	recv := ""
+	if strings.Contains(name, ".") {
+		parts := strings.SplitN(name, ".", 2)
+		recv, name = parts[0], parts[1]
+	}
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Name.Name == name && ReceiverName(decl) == recv {
+				return decl, token.ILLEGAL
+			}
+		case *ast.GenDecl:
+			if recv != "" {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.Name == name {
+						return spec, decl.Tok
+					}
+				case *ast.ValueSpec:
+					for _, ident := range spec.Names {
+						if ident.Name == name {
+							return spec, decl.Tok
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil, token.ILLEGAL
+}
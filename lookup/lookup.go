@@ -53,6 +53,44 @@ func (k *Lookup) Toplevel(name string) *Result {
 	}
 }
 
+// All : all toplevel declarations and methods, in order of declaration
+func (k *Lookup) All() []*Result {
+	var r []*Result
+	for _, f := range k.Files {
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if IsMethod(decl) {
+					name := ReceiverName(decl)
+					ob := k.lookup(name)
+					if ob == nil {
+						ob = ast.NewObj(ast.Typ, name) // defined in other package files
+					}
+					r = append(r, &Result{Type: TypeMethod, Object: ob, FuncDecl: decl})
+				} else if ob := f.Scope.Lookup(decl.Name.Name); ob != nil {
+					r = append(r, &Result{Type: TypeToplevel, Object: ob})
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					var names []*ast.Ident
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						names = []*ast.Ident{spec.Name}
+					case *ast.ValueSpec:
+						names = spec.Names
+					}
+					for _, name := range names {
+						if ob := f.Scope.Lookup(name.Name); ob != nil {
+							r = append(r, &Result{Type: TypeToplevel, Object: ob})
+						}
+					}
+				}
+			}
+		}
+	}
+	return r
+}
+
 // AllMethods :
 func (k *Lookup) AllMethods(obname string) []*Result {
 	ob := k.lookup(obname)
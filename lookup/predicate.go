@@ -20,3 +20,18 @@ func IsSameTypeOrPointer(ob *ast.Object, fn ast.Node) bool {
 		return false
 	}
 }
+
+// ReceiverName : type name of method's receiver (e.g. `func (s *S) M()` -> "S")
+func ReceiverName(fn *ast.FuncDecl) string {
+	if !IsMethod(fn) || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	typ := fn.Recv.List[0].Type
+	if t, ok := typ.(*ast.StarExpr); ok {
+		typ = t.X
+	}
+	if t, ok := typ.(*ast.Ident); ok {
+		return t.Name
+	}
+	return ""
+}
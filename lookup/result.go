@@ -2,6 +2,7 @@ package lookup
 
 import (
 	"go/ast"
+	"go/token"
 )
 
 // Type :
@@ -19,6 +20,8 @@ type Result struct {
 	Type     Type
 	FuncDecl *ast.FuncDecl
 	Object   *ast.Object
+
+	Fset *token.FileSet // FileSet of the file declaring it (nil if unknown)
 }
 
 // Name :
@@ -31,3 +34,29 @@ func (r *Result) Name() string {
 	}
 	return "<nil>"
 }
+
+// FullName : name with receiver (e.g. "S.String")
+func (r *Result) FullName() string {
+	if r.Type == TypeMethod {
+		if name := ReceiverName(r.FuncDecl); name != "" {
+			return name + "." + r.FuncDecl.Name.Name
+		}
+		if r.Object != nil {
+			return r.Object.Name + "." + r.FuncDecl.Name.Name
+		}
+	}
+	return r.Name()
+}
+
+// Node : declaration node (*ast.FuncDecl or ast.Spec)
+func (r *Result) Node() ast.Node {
+	switch r.Type {
+	case TypeToplevel:
+		if node, ok := r.Object.Decl.(ast.Node); ok {
+			return node
+		}
+	case TypeMethod:
+		return r.FuncDecl
+	}
+	return nil
+}
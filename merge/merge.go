@@ -0,0 +1,155 @@
+package merge
+
+import (
+	"go/ast"
+	"go/token"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action"
+	appendaction "github.com/podhmo/astknife/action/append"
+	"github.com/podhmo/astknife/deps"
+	"github.com/podhmo/astknife/equal"
+	"github.com/podhmo/astknife/lookup"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// Status : merged status of each declaration
+type Status string
+
+const (
+	// StatusUnchanged : same in generated and local
+	StatusUnchanged = Status("unchanged")
+	// StatusGenerated : changed only in generated, so taken from generated
+	StatusGenerated = Status("generated")
+	// StatusLocal : changed only in local, so kept as local
+	StatusLocal = Status("local")
+	// StatusConflict : changed in both generated and local
+	StatusConflict = Status("conflict")
+)
+
+// Entry :
+type Entry struct {
+	Name      string // e.g. "S", "S.String"
+	Status    Status
+	Base      *lookup.Result // nil if not existed
+	Generated *lookup.Result // nil if not existed
+	Local     *lookup.Result // nil if not existed
+}
+
+// Result :
+type Result struct {
+	Fset    *token.FileSet
+	File    *ast.File // merged file (= local file, modified in place)
+	Entries []*Entry
+}
+
+// Conflicts :
+func (r *Result) Conflicts() []*Entry {
+	var conflicts []*Entry
+	for _, e := range r.Entries {
+		if e.Status == StatusConflict {
+			conflicts = append(conflicts, e)
+		}
+	}
+	return conflicts
+}
+
+// Merge : three-way merge per declaration. local is modified in place.
+// all files must be parsed with the same fset.
+func Merge(fset *token.FileSet, base, generated, local *ast.File) (*Result, error) {
+	baseM, baseOrder := collect(base)
+	genM, genOrder := collect(generated)
+	localM, localOrder := collect(local)
+
+	var names []string
+	seen := map[string]bool{}
+	for _, order := range [][]string{localOrder, genOrder, baseOrder} {
+		for _, name := range order {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	k := lookup.New(local)
+	result := &Result{Fset: fset, File: local}
+	for _, name := range names {
+		e := &Entry{Name: name, Base: baseM[name], Generated: genM[name], Local: localM[name]}
+		result.Entries = append(result.Entries, e)
+
+		switch {
+		case same(e.Generated, e.Local):
+			e.Status = StatusUnchanged
+		case same(e.Base, e.Local):
+			e.Status = StatusGenerated
+		case same(e.Base, e.Generated):
+			e.Status = StatusLocal
+		default:
+			e.Status = StatusConflict
+		}
+
+		if e.Status != StatusGenerated {
+			continue
+		}
+
+		var err error
+		switch {
+		case e.Generated == nil:
+			_, err = action.Delete(k, local, e.Local)
+		case e.Local == nil:
+			if group := constGroup(generated, e.Generated); group != nil {
+				_, err = appendaction.GenDeclToFile(local, group) // keep iota
+			} else {
+				_, err = action.Append(k, local, e.Generated)
+			}
+		default:
+			_, err = action.Replace(k, local, e.Generated)
+		}
+		if err != nil {
+			return result, errors.Wrapf(err, "merge %s", name)
+		}
+		for _, im := range deps.ImportsOf(generated, e.Generated.Node()) {
+			astutil.AddNamedImport(fset, local, im.Name, im.Path)
+		}
+	}
+	return result, nil
+}
+
+// constGroup : the const declaration including r, if it has multiple specs (e.g. `const ( A = iota; B )`)
+func constGroup(f *ast.File, r *lookup.Result) *ast.GenDecl {
+	if r.Type != lookup.TypeToplevel || r.Object.Kind != ast.Con {
+		return nil
+	}
+	for _, decl := range f.Decls {
+		if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.CONST && len(decl.Specs) > 1 {
+			for _, spec := range decl.Specs {
+				if spec == r.Object.Decl {
+					return decl
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func collect(f *ast.File) (map[string]*lookup.Result, []string) {
+	m := map[string]*lookup.Result{}
+	var order []string
+	for _, r := range lookup.New(f).All() {
+		name := r.FullName()
+		if _, ok := m[name]; ok {
+			continue
+		}
+		m[name] = r
+		order = append(order, name)
+	}
+	return m, order
+}
+
+func same(x, y *lookup.Result) bool {
+	if x == nil || y == nil {
+		return x == nil && y == nil
+	}
+	return equal.Node(x.Node(), y.Node())
+}
@@ -0,0 +1,182 @@
+package merge
+
+import (
+	"bytes"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	base := `
+package p
+
+type S struct {
+	Name string
+}
+
+func (s *S) String() string {
+	return s.Name
+}
+
+func Hello() string {
+	return "hello"
+}
+
+func Bye() string {
+	return "bye"
+}
+`
+	generated := `
+package p
+
+type S struct {
+	Name string
+	Age  int // *generated*
+}
+
+func (s *S) String() string {
+	return "generated"
+}
+
+func Hello() string {
+	return "hello"
+}
+
+func Bye() string {
+	return "bye"
+}
+
+func Added() {}
+`
+	local := `
+package p
+
+type S struct {
+	Name string
+}
+
+func (s *S) String() string {
+	return "local"
+}
+
+func Hello() string {
+	return "*local hello*"
+}
+
+func Bye() string {
+	return "bye"
+}
+`
+	fset := token.NewFileSet()
+	parse := func(name, source string) *ast.File {
+		f, err := parser.ParseFile(fset, name, source, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	result, err := Merge(fset, parse("base", base), parse("generated", generated), parse("local", local))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]Status{
+		"S":        StatusGenerated,
+		"S.String": StatusConflict,
+		"Hello":    StatusLocal,
+		"Bye":      StatusUnchanged,
+		"Added":    StatusGenerated,
+	}
+	for _, e := range result.Entries {
+		if expected[e.Name] != e.Status {
+			t.Errorf("%s: expected status is %q, but got %q", e.Name, expected[e.Name], e.Status)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := result.FprintWithConflictMarkers(&b); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("output\n%s\n", b.String())
+	for _, s := range []string{"*generated*", "*local hello*", "func Added()", "<<<<<<< local", `return "local"`, `return "generated"`} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("should contain %q", s)
+		}
+	}
+}
+
+func TestMergeAdded(t *testing.T) {
+	base := `
+package p
+`
+	generated := `
+package p
+
+import "strings"
+
+var x, y int
+
+const (
+	A = iota
+	B
+)
+
+func Upper(s string) string {
+	return strings.ToUpper(s)
+}
+`
+	local := `
+package p
+
+import "fmt"
+
+var _ = fmt.Sprint
+`
+	fset := token.NewFileSet()
+	parse := func(name, source string) *ast.File {
+		f, err := parser.ParseFile(fset, name, source, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	result, err := Merge(fset, parse("base", base), parse("generated", generated), parse("local", local))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := result.FprintWithConflictMarkers(&b); err != nil {
+		t.Fatal(err)
+	}
+	code := b.String()
+	t.Logf("output\n%s\n", code)
+
+	if n := strings.Count(code, "var x, y int"); n != 1 {
+		t.Errorf("multi-name var must be appended once, but %d times", n)
+	}
+	if !strings.Contains(code, "const (\n\tA\t= iota\n\tB\n)") {
+		t.Errorf("const group must be kept as a whole")
+	}
+	if !strings.Contains(code, `"strings"`) {
+		t.Errorf("the import used by generated code must be added")
+	}
+
+	// type-checked
+	mfset := token.NewFileSet()
+	merged, err := parser.ParseFile(mfset, "merged", code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(mfset, "source", nil)}
+	if _, err := conf.Check("p", mfset, []*ast.File{merged}, nil); err != nil {
+		t.Errorf("merged code must be type-checked, but %s", err)
+	}
+}
@@ -0,0 +1,134 @@
+package merge
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+
+	"github.com/podhmo/astknife/lookup"
+	"github.com/podhmo/astknife/printer"
+)
+
+// FprintCode : print merged file (conflicted declarations are kept as local)
+func (r *Result) FprintCode(w io.Writer) error {
+	return printer.FprintCode(w, r.Fset, r.File)
+}
+
+// FprintWithConflictMarkers : print merged file, conflicted declarations are surrounded by conflict markers
+func (r *Result) FprintWithConflictMarkers(w io.Writer) error {
+	conflicts := map[ast.Node]*Entry{}
+	var orphans []*Entry // deleted in local, but modified in generated
+	for _, e := range r.Conflicts() {
+		if e.Local == nil {
+			orphans = append(orphans, e)
+			continue
+		}
+		conflicts[e.Local.Node()] = e
+	}
+
+	if _, err := fmt.Fprintf(w, "package %s\n", r.File.Name.Name); err != nil {
+		return err
+	}
+	for _, decl := range r.File.Decls {
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if e, ok := conflicts[decl]; ok {
+				if err := r.fprintConflict(w, e); err != nil {
+					return err
+				}
+				continue
+			}
+		case *ast.GenDecl:
+			hasConflict := false
+			for _, spec := range decl.Specs {
+				if _, ok := conflicts[spec]; ok {
+					hasConflict = true
+				}
+			}
+			if hasConflict {
+				// split into each spec
+				for _, spec := range decl.Specs {
+					if e, ok := conflicts[spec]; ok {
+						if err := r.fprintConflict(w, e); err != nil {
+							return err
+						}
+						continue
+					}
+					if err := r.fprintNode(w, decl.Tok, spec); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+		}
+		if err := r.fprintNode(w, token.ILLEGAL, decl); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range orphans {
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+		if err := r.fprintConflict(w, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Result) fprintConflict(w io.Writer, e *Entry) error {
+	if _, err := io.WriteString(w, "<<<<<<< local\n"); err != nil {
+		return err
+	}
+	if e.Local != nil {
+		if err := r.fprintNode(w, tokenOf(e.Local), e.Local.Node()); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, "=======\n"); err != nil {
+		return err
+	}
+	if e.Generated != nil {
+		if err := r.fprintNode(w, tokenOf(e.Generated), e.Generated.Node()); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, ">>>>>>> generated\n")
+	return err
+}
+
+// fprintNode : print declaration, spec is printed with its keyword (e.g. `type S struct{}`)
+func (r *Result) fprintNode(w io.Writer, tok token.Token, node ast.Node) error {
+	if _, ok := node.(ast.Spec); ok && tok != token.ILLEGAL {
+		if _, err := fmt.Fprintf(w, "%s ", tok); err != nil {
+			return err
+		}
+	}
+	if err := printer.FprintCode(w, r.Fset, node); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func tokenOf(r *lookup.Result) token.Token {
+	if r.Type != lookup.TypeToplevel {
+		return token.ILLEGAL
+	}
+	switch r.Object.Kind {
+	case ast.Typ:
+		return token.TYPE
+	case ast.Con:
+		return token.CONST
+	case ast.Var:
+		return token.VAR
+	default:
+		return token.ILLEGAL
+	}
+}
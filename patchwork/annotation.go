@@ -0,0 +1,42 @@
+package patchwork
+
+import (
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action"
+	"github.com/podhmo/astknife/annotation"
+	"github.com/podhmo/astknife/lookup"
+)
+
+// ApplyAnnotated : apply the override file driven by magic comments (e.g. `//astknife:replace`)
+func (pf *File) ApplyAnnotated(src *File) error {
+	directives, err := annotation.Parse(src.Fset, src.File)
+	if err != nil {
+		return err
+	}
+
+	wrapped := src.Wrap(pf.Patchwork)
+	for _, d := range directives {
+		var err error
+		switch d.Kind {
+		case annotation.KindReplace:
+			_, err = pf.Replace(wrapped.Lookup(d.Name))
+		case annotation.KindAppend:
+			_, err = pf.Append(wrapped.Lookup(d.Name))
+		case annotation.KindDelete:
+			// only the destination is searched (e.g. the method of same name in other files is never deleted)
+			node, _ := lookup.FindInFile(pf.File, d.Name)
+			r := pf.Lookup(d.Name)
+			if node == nil || r == nil || r.Node() != node {
+				err = action.ErrTargetNotFound
+				break
+			}
+			_, err = pf.Delete(r)
+		case annotation.KindKeep:
+			continue
+		}
+		if err != nil && errors.Cause(err) != action.ErrUnchanged {
+			return errors.Wrapf(err, "%s: %s %s", src.Fset.Position(d.Pos), d.Kind, d.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package patchwork
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestApplyAnnotated
+func TestApplyAnnotated(t *testing.T) {
+	source := `
+package p
+type S struct {
+	Before string ` + "`" + `replaced:"false"` + "`" + `
+}
+func (s *S) String() string {
+	return ` + "`" + `replaced:"false"` + "`" + `
+}
+
+func Hello() string {
+	return "hello"
+}
+`
+	type C struct {
+		source2  string
+		msg      string
+		hasErr   bool
+		contains []string
+		excludes []string
+	}
+
+	candidates := []C{
+		{
+			msg: "replace, append, delete",
+			source2: `
+package p
+
+//astknife:replace
+func (s *S) String() string {
+	return ` + "`" + `replaced:"true"` + "`" + `
+}
+
+//astknife:append
+func Bye() string {
+	return "bye"
+}
+
+//astknife:delete Hello
+`,
+			contains: []string{`replaced:"true"`, "func Bye()"},
+			excludes: []string{"func Hello()"},
+		},
+		{
+			msg: "keep",
+			source2: `
+package p
+
+//astknife:keep
+type S struct {
+	After string
+}
+`,
+			contains: []string{`replaced:"false"`},
+		},
+		{
+			msg: "unknown directive",
+			source2: `
+package p
+
+//astknife:upsert
+func Hello() string {
+	return "hello"
+}
+`,
+			hasErr: true,
+		},
+		{
+			msg: "conflicted directive",
+			source2: `
+package p
+
+//astknife:replace
+func Hello() string {
+	return "hello"
+}
+
+//astknife:delete Hello
+`,
+			hasErr: true,
+		},
+	}
+
+	for _, c := range candidates {
+		c := c
+		t.Run(c.msg, func(t *testing.T) {
+			pf := NewPatchwork().MustParseFile("f0", source)
+			pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", c.source2)
+
+			err := pf.ApplyAnnotated(pf1)
+			if c.hasErr {
+				t.Logf("should error %s", err)
+				if err == nil {
+					t.Fatal("error is expected, but no error")
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			var b bytes.Buffer
+			if err := pf.FprintCode(&b); err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("output\n%s\n", b.String())
+
+			for _, s := range c.contains {
+				if !strings.Contains(b.String(), s) {
+					t.Errorf("should contain %q", s)
+				}
+			}
+			for _, s := range c.excludes {
+				if strings.Contains(b.String(), s) {
+					t.Errorf("should not contain %q", s)
+				}
+			}
+		})
+	}
+}
+
+// TestApplyAnnotatedDelete : only the destination is searched
+func TestApplyAnnotatedDelete(t *testing.T) {
+	pw := NewPatchwork()
+	pf := pw.MustParseFile("f0", "package p\ntype S struct{}\nfunc (s *S) Hello() string {\n\treturn \"hello\"\n}\n")
+	other := pw.MustParseFile("f1", "package p\nfunc (s *S) Bye() string {\n\treturn \"bye\"\n}\n")
+
+	candidates := []string{"S.Bye", "Missing"}
+	for _, name := range candidates {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			src := NewPatchwork(WithFileSet(pw.Fset)).MustParseFile("override", "package p\n\n//astknife:delete "+name+"\n")
+			err := pf.ApplyAnnotated(src)
+			t.Logf("should error %s", err)
+			if err == nil {
+				t.Fatal("error is expected, but no error")
+			}
+			if code, _ := printerString(other); !strings.Contains(code, "func (s *S) Bye() string") {
+				t.Errorf("declarations in other files must not be deleted, but\n%s", code)
+			}
+		})
+	}
+}
+
+// TestApplyAnnotatedWithOtherFileSet : positions of the override file are reported with its own FileSet
+func TestApplyAnnotatedWithOtherFileSet(t *testing.T) {
+	source := `
+package p
+
+func Hello() string {
+	return "hello"
+}
+`
+	candidates := []struct {
+		msg      string
+		source2  string
+		position string
+	}{
+		{
+			msg: "unknown directive",
+			source2: `
+package p
+
+//astknife:upsert
+func Hello() string {
+	return "hello"
+}
+`,
+			position: "override.go:4:1",
+		},
+		{
+			msg: "failed action",
+			source2: `
+package p
+
+func Hello() string {
+	return "hello"
+}
+
+//astknife:replace
+func Bye() string {
+	return "bye"
+}
+`,
+			position: "override.go:8:1",
+		},
+	}
+
+	for _, c := range candidates {
+		c := c
+		t.Run(c.msg, func(t *testing.T) {
+			pf := NewPatchwork().MustParseFile("dst.go", source)
+			pf1 := NewPatchwork().MustParseFile("override.go", c.source2)
+
+			err := pf.ApplyAnnotated(pf1)
+			if err == nil {
+				t.Fatal("error is expected, but no error")
+			}
+			if !strings.Contains(err.Error(), c.position) {
+				t.Errorf("expected %s is reported, but %s", c.position, err)
+			}
+		})
+	}
+}
@@ -2,7 +2,13 @@ package patchwork
 
 import (
 	"bytes"
+	"strings"
 	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action"
+	"github.com/podhmo/astknife/action/failure"
+	"github.com/podhmo/astknife/lookup"
 )
 
 // TestAppend
@@ -103,3 +109,189 @@ func (s *S) Hello() string {
 		})
 	}
 }
+
+// TestAppendAlreadyExists
+func TestAppendAlreadyExists(t *testing.T) {
+	source := `
+package p
+type S struct {}
+`
+	pf := NewPatchwork().MustParseFile("f0", source)
+	pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source)
+
+	_, err := pf.Append(pf1.Wrap(pf.Patchwork).Lookup("S"))
+	t.Logf("should error %s", err)
+
+	var target *failure.AlreadyExistsError
+	if !errors.As(err, &target) {
+		t.Fatalf("AlreadyExistsError is expected, but got %T", err)
+	}
+	if target.Source.Filename != "f1" || target.Target.Filename != "f0" {
+		t.Errorf("unexpected positions, source=%s, target=%s", target.Source, target.Target)
+	}
+	if target.Result == nil || target.Result.Name() != "S" {
+		t.Errorf("result must be set")
+	}
+}
+
+// TestAppendDuplicateMethod
+func TestAppendDuplicateMethod(t *testing.T) {
+	source := `
+package p
+type S struct {}
+`
+	sibling := `
+package p
+func (s *S) Hello() string {
+	return "hello"
+}
+`
+	source2 := `
+package p
+func (s *S) Hello() string {
+	return "*replaced*"
+}
+`
+	t.Run("duplicated in other file", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", source)
+		pf.MustParseFile("sibling", sibling)
+		pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2).Wrap(pf.Patchwork)
+
+		_, err := pf.Append(pf1.Lookup("S.Hello"))
+		t.Logf("should error %s", err)
+
+		var target *failure.AlreadyExistsError
+		if !errors.As(err, &target) {
+			t.Fatalf("AlreadyExistsError is expected, but got %v", err)
+		}
+		if target.Target.Filename != "sibling" {
+			t.Errorf("the file defining the method must be reported, but got %s", target.Target)
+		}
+	})
+
+	t.Run("replace duplicated", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", source)
+		psibling := pf.MustParseFile("sibling", sibling)
+		pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2).Wrap(pf.Patchwork)
+
+		ok, err := pf.Append(pf1.Lookup("S.Hello"), action.WithReplaceDuplicates())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Fatal("must be replaced")
+		}
+
+		var b bytes.Buffer
+		if err := psibling.FprintCode(&b); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(b.String(), "*replaced*") {
+			t.Errorf("the method must be replaced in the file defining it\n%s", b.String())
+		}
+	})
+
+	t.Run("replace duplicated, but protected", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", source)
+		psibling := pf.MustParseFile("sibling", "\npackage p\n\n//astknife:keep\n"+strings.TrimPrefix(sibling, "\npackage p\n"))
+		pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2).Wrap(pf.Patchwork)
+
+		ok, err := pf.Append(pf1.Lookup("S.Hello"), action.WithProtect(), action.WithReplaceDuplicates())
+		if !action.IsConflict(err) {
+			t.Fatalf("conflict is expected, but got %v", err)
+		}
+		if ok {
+			t.Fatal("must not be replaced")
+		}
+		if code, _ := printerString(psibling); strings.Contains(code, "*replaced*") {
+			t.Errorf("the protected method must not be replaced\n%s", code)
+		}
+	})
+
+	t.Run("replace duplicated, but unchanged", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", source)
+		pf.MustParseFile("sibling", sibling)
+		pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", sibling).Wrap(pf.Patchwork)
+
+		_, err := pf.Append(pf1.Lookup("S.Hello"), action.WithReplaceDuplicates())
+		if errors.Cause(err) != action.ErrUnchanged {
+			t.Fatalf("ErrUnchanged is expected, but got %v", err)
+		}
+	})
+
+	t.Run("replace duplicated in other file, in transaction", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", source)
+		psibling := pf.MustParseFile("sibling", sibling)
+		pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2).Wrap(pf.Patchwork)
+
+		pf.Begin()
+		_, err := pf.Append(pf1.Lookup("S.Hello"), action.WithReplaceDuplicates())
+		pf.Rollback()
+
+		var target *failure.AlreadyExistsError
+		if !errors.As(err, &target) {
+			t.Fatalf("AlreadyExistsError is expected (other files are not rolled back), but got %v", err)
+		}
+		if code, _ := printerString(psibling); strings.Contains(code, "*replaced*") {
+			t.Errorf("the method in other file must not be replaced\n%s", code)
+		}
+	})
+
+	t.Run("replace duplicated in destination, in transaction", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", source+sibling[len("\npackage p"):])
+		pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2).Wrap(pf.Patchwork)
+
+		pf.Begin()
+		if _, err := pf.Append(pf1.Lookup("S.Hello"), action.WithReplaceDuplicates()); err != nil {
+			pf.Rollback()
+			t.Fatal(err)
+		}
+		if err := pf.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		if code, _ := printerString(pf); !strings.Contains(code, "*replaced*") {
+			t.Errorf("the method in destination must be replaced\n%s", code)
+		}
+	})
+}
+
+// TestAppendWithOtherFileSet : positions of replacement are resolved with its own FileSet
+func TestAppendWithOtherFileSet(t *testing.T) {
+	source := `
+package p
+func Hello() string {
+	return "hello"
+}
+`
+	source2 := `
+package p
+
+import "fmt"
+
+// Hello :
+func Hello() string {
+	return fmt.Sprint("hello")
+}
+`
+	pf := NewPatchwork().MustParseFile("dst.go", source)
+	src := NewPatchwork().MustParseFile("override.go", source2)
+
+	for msg, r := range map[string]*lookup.Result{
+		"lookup":  src.Lookup("Hello"),
+		"wrapped": src.Wrap(pf.Patchwork).Lookup("Hello"),
+	} {
+		t.Run(msg, func(t *testing.T) {
+			_, err := pf.Append(r)
+			var target *failure.AlreadyExistsError
+			if !errors.As(err, &target) {
+				t.Fatalf("AlreadyExistsError is expected, but got %v", err)
+			}
+			if expected := "override.go:7:1"; target.Source.String() != expected {
+				t.Errorf("expected source is %s, but %s", expected, target.Source)
+			}
+			if expected := "dst.go:3:1"; target.Target.String() != expected {
+				t.Errorf("expected target is %s, but %s", expected, target.Target)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package patchwork
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action"
+	"github.com/podhmo/astknife/lookup"
+)
+
+// OpType :
+type OpType string
+
+const (
+	// OpAppend : action.Append
+	OpAppend = OpType("append")
+	// OpReplace : action.Replace
+	OpReplace = OpType("replace")
+	// OpAppendOrReplace : action.AppendOrReplace
+	OpAppendOrReplace = OpType("upsert")
+	// OpDelete : action.Delete
+	OpDelete = OpType("delete")
+)
+
+// Op : operation of batch
+type Op struct {
+	Type   OpType
+	Result *lookup.Result
+	Name   string // used for report, when Result is nil
+}
+
+// Status : status of each operation
+type Status string
+
+const (
+	// StatusApplied :
+	StatusApplied = Status("applied")
+	// StatusSkipped : no effect (e.g. target not found, unchanged)
+	StatusSkipped = Status("skipped")
+	// StatusFailed :
+	StatusFailed = Status("failed")
+)
+
+// OpReport :
+type OpReport struct {
+	Type     OpType          `json:"type"`
+	Name     string          `json:"name"`
+	Status   Status          `json:"status"`
+	Position *token.Position `json:"position,omitempty"` // position of replacement
+	Message  string          `json:"message,omitempty"`
+	Err      error           `json:"-"`
+
+	node ast.Node // applied declaration
+}
+
+// Report : report of ApplyAll
+type Report struct {
+	Ops        []*OpReport `json:"ops"`
+	RolledBack bool        `json:"rolledBack,omitempty"` // with WithAtomic(), all operations are rolled back on failure
+}
+
+// Applied :
+func (r *Report) Applied() []*OpReport {
+	return r.filter(StatusApplied)
+}
+
+// Skipped :
+func (r *Report) Skipped() []*OpReport {
+	return r.filter(StatusSkipped)
+}
+
+// Failed :
+func (r *Report) Failed() []*OpReport {
+	return r.filter(StatusFailed)
+}
+
+func (r *Report) filter(status Status) []*OpReport {
+	var ops []*OpReport
+	for _, op := range r.Ops {
+		if op.Status == status {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// Err : combined error of failed operations
+func (r *Report) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	return &BatchError{Failed: failed}
+}
+
+// Fprint : print as text (e.g. `applied replace S.String (f1:3:1)`)
+func (r *Report) Fprint(w io.Writer) error {
+	for _, op := range r.Ops {
+		line := fmt.Sprintf("%-7s %s %s", op.Status, op.Type, op.Name)
+		if op.Position != nil {
+			line += fmt.Sprintf(" (%s)", op.Position)
+		}
+		if op.Message != "" {
+			line += ": " + op.Message
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FprintJSON : print as JSON
+func (r *Report) FprintJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// BatchError :
+type BatchError struct {
+	Failed []*OpReport
+}
+
+func (e *BatchError) Error() string {
+	messages := make([]string, len(e.Failed))
+	for i, op := range e.Failed {
+		messages[i] = fmt.Sprintf("%s %s: %s", op.Type, op.Name, op.Message)
+	}
+	return fmt.Sprintf("%d operations are failed (%s)", len(e.Failed), strings.Join(messages, "; "))
+}
+
+// ApplyConfig : options of ApplyAll
+type ApplyConfig struct {
+	ContinueOnError bool
+	Atomic          bool
+	Rebuild         bool
+	ActionOptions   []func(*action.Config)
+}
+
+// WithContinueOnError : continue past errors, and collect them
+func WithContinueOnError() func(*ApplyConfig) {
+	return func(c *ApplyConfig) {
+		c.ContinueOnError = true
+	}
+}
+
+// WithAtomic : all-or-nothing, if any operation is failed, the file is rolled back
+func WithAtomic() func(*ApplyConfig) {
+	return func(c *ApplyConfig) {
+		c.Atomic = true
+	}
+}
+
+// WithRebuild : re-resolve the file after the batch (see File.Rebuild)
+func WithRebuild() func(*ApplyConfig) {
+	return func(c *ApplyConfig) {
+		c.Rebuild = true
+	}
+}
+
+// WithActionOptions : options passed to each action
+func WithActionOptions(options ...func(*action.Config)) func(*ApplyConfig) {
+	return func(c *ApplyConfig) {
+		c.ActionOptions = append(c.ActionOptions, options...)
+	}
+}
+
+// ApplyAll : apply operations in order. (stop at the first error, by default)
+func (pf *File) ApplyAll(ops []Op, options ...func(*ApplyConfig)) (*Report, error) {
+	c := &ApplyConfig{}
+	for _, op := range options {
+		op(c)
+	}
+
+	report := &Report{}
+	if c.Atomic {
+		pf.Begin()
+	}
+	for _, op := range ops {
+		opReport := &OpReport{Type: op.Type, Name: op.Name}
+		if op.Result != nil {
+			opReport.Name = op.Result.FullName()
+			if node := op.Result.Node(); node != nil {
+				opReport.Position = position(pf.fileSetOf(op.Result), node.Pos())
+				opReport.node = node
+			}
+		}
+		report.Ops = append(report.Ops, opReport)
+
+		ok, err := pf.apply(op, c.ActionOptions)
+		switch {
+		case err == nil && ok:
+			opReport.Status = StatusApplied
+		case err == nil || action.IsNoEffect(err):
+			opReport.Status = StatusSkipped
+			if err != nil {
+				opReport.Message = err.Error()
+				opReport.Err = err
+			}
+		default:
+			opReport.Status = StatusFailed
+			opReport.Message = err.Error()
+			opReport.Err = err
+			if !c.ContinueOnError {
+				return report, pf.finish(c, report, err)
+			}
+		}
+	}
+	return report, pf.finish(c, report, report.Err())
+}
+
+func (pf *File) finish(c *ApplyConfig, report *Report, err error) error {
+	if c.Rebuild && (err == nil || !c.Atomic) {
+		if rerr := pf.Rebuild(); rerr != nil {
+			if c.Atomic {
+				pf.Rollback()
+				report.RolledBack = true
+			}
+			return rerr
+		}
+	}
+	if !c.Atomic {
+		return err
+	}
+	if err == nil {
+		return pf.Commit()
+	}
+	if rerr := pf.Rollback(); rerr != nil {
+		return rerr
+	}
+	report.RolledBack = true
+	return err
+}
+
+// fileSetOf : FileSet of the file declaring r (if unknown, the FileSet of destination)
+func (pf *File) fileSetOf(r *lookup.Result) *token.FileSet {
+	if r != nil && r.Fset != nil {
+		return r.Fset
+	}
+	return pf.Fset
+}
+
+// position : nil, if not resolved
+func position(fset *token.FileSet, pos token.Pos) *token.Position {
+	if !pos.IsValid() {
+		return nil
+	}
+	p := fset.Position(pos)
+	if !p.IsValid() {
+		return nil
+	}
+	return &p
+}
+
+func (pf *File) apply(op Op, options []func(*action.Config)) (bool, error) {
+	switch op.Type {
+	case OpAppend:
+		return pf.Append(op.Result, options...)
+	case OpReplace:
+		return pf.Replace(op.Result, options...)
+	case OpAppendOrReplace:
+		return pf.AppendOrReplace(op.Result, options...)
+	case OpDelete:
+		return pf.Delete(op.Result, options...)
+	default:
+		return false, errors.Errorf("unsupported operation %q", op.Type)
+	}
+}
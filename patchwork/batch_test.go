@@ -0,0 +1,114 @@
+package patchwork
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestApplyAll
+func TestApplyAll(t *testing.T) {
+	source := `
+package p
+type S struct {}
+func Hello() string {
+	return "hello"
+}
+`
+	source2 := `
+package p
+type S struct {}
+type S2 struct {}
+func Hello() string {
+	return "hello!"
+}
+`
+	type C struct {
+		msg             string
+		continueOnError bool
+		applied         int
+		skipped         int
+		failed          int
+	}
+
+	candidates := []C{
+		{
+			msg:     "stop at the first error",
+			applied: 1,
+			skipped: 1,
+			failed:  1,
+		},
+		{
+			msg:             "continue on error",
+			continueOnError: true,
+			applied:         2,
+			skipped:         1,
+			failed:          1,
+		},
+	}
+
+	for _, c := range candidates {
+		c := c
+		t.Run(c.msg, func(t *testing.T) {
+			pf := NewPatchwork().MustParseFile("f0", source)
+			pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2).Wrap(pf.Patchwork)
+
+			ops := []Op{
+				{Type: OpAppend, Result: pf1.Lookup("S2")},
+				{Type: OpReplace, Result: pf1.Lookup("S")},    // unchanged
+				{Type: OpAppend, Result: pf1.Lookup("Hello")}, // already existed
+				{Type: OpReplace, Result: pf1.Lookup("Hello")},
+			}
+			var options []func(*ApplyConfig)
+			if c.continueOnError {
+				options = append(options, WithContinueOnError())
+			}
+
+			report, err := pf.ApplyAll(ops, options...)
+			if err == nil {
+				t.Fatal("error is expected, but no error")
+			}
+
+			var b bytes.Buffer
+			if err := report.Fprint(&b); err != nil {
+				t.Fatal(err)
+			}
+			if err := report.FprintJSON(&b); err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("report\n%s", b.String())
+
+			if len(report.Applied()) != c.applied {
+				t.Errorf("applied: expected %d, but got %d", c.applied, len(report.Applied()))
+			}
+			if len(report.Skipped()) != c.skipped {
+				t.Errorf("skipped: expected %d, but got %d", c.skipped, len(report.Skipped()))
+			}
+			if len(report.Failed()) != c.failed {
+				t.Errorf("failed: expected %d, but got %d", c.failed, len(report.Failed()))
+			}
+		})
+	}
+}
+
+// TestApplyAllWithOtherFileSet : positions of operations are resolved with the FileSet of replacement
+func TestApplyAllWithOtherFileSet(t *testing.T) {
+	source := `
+package p
+type S struct {}
+`
+	source2 := `
+package p
+
+type S2 struct {}
+`
+	pf := NewPatchwork().MustParseFile("dst.go", source)
+	src := NewPatchwork().MustParseFile("override.go", source2)
+
+	report, err := pf.ApplyAll([]Op{{Type: OpAppend, Result: src.Lookup("S2")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "override.go:4:6"; report.Ops[0].Position == nil || report.Ops[0].Position.String() != expected {
+		t.Errorf("expected position is %s, but %v", expected, report.Ops[0].Position)
+	}
+}
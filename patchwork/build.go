@@ -0,0 +1,111 @@
+package patchwork
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/podhmo/astknife/lookup"
+)
+
+// PackageMismatchError : the file of other package is parsed, without WithMixedPackages()
+type PackageMismatchError struct {
+	Filename string
+	Package  string
+	Expected string
+}
+
+func (e *PackageMismatchError) Error() string {
+	return fmt.Sprintf("%s: package %s is mismatched, expected package %s", e.Filename, e.Package, e.Expected)
+}
+
+// register : add the file to lookup, if its package and build constraint are matched.
+// the excluded file has own lookup, so it can be used as the source of actions (e.g. by Wrap)
+func (pw *Patchwork) register(filename string, file *ast.File) (*File, error) {
+	f := &File{Patchwork: pw, File: file, Package: file.Name.Name, Constraint: buildConstraint(file)}
+
+	if pw.Package == "" {
+		pw.Package = f.Package
+	}
+	if f.Package != pw.Package {
+		if !pw.MixedPackages {
+			return nil, &PackageMismatchError{Filename: filename, Package: f.Package, Expected: pw.Package}
+		}
+		f.Excluded = true
+	}
+	if !f.Excluded && pw.Context != nil {
+		matched, err := pw.match(filename, file)
+		if err != nil {
+			return nil, err
+		}
+		f.Excluded = !matched
+	}
+
+	if f.Excluded {
+		f.Patchwork = &Patchwork{Fset: pw.Fset, lookup: lookup.New(file), Context: pw.Context, Package: f.Package}
+		return f, nil
+	}
+	pw.lookup.Files = append(pw.lookup.Files, file)
+	return f, nil
+}
+
+// match : the file is matched with build context, or not (both of filename (e.g. xxx_windows.go) and build constraints are checked)
+func (pw *Patchwork) match(filename string, file *ast.File) (bool, error) {
+	name := filepath.Base(filename)
+	if !strings.HasSuffix(name, ".go") {
+		name += ".go"
+	}
+
+	// header of file, for build.Context.MatchFile
+	var b strings.Builder
+	for _, cg := range file.Comments {
+		if cg.Pos() >= file.Package {
+			break
+		}
+		for _, c := range cg.List {
+			b.WriteString(c.Text + "\n")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("package " + file.Name.Name + "\n")
+
+	ctxt := *pw.Context
+	ctxt.OpenFile = func(string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(b.String())), nil
+	}
+	return ctxt.MatchFile(".", name)
+}
+
+// buildConstraint : `//go:build` expression, or `// +build` lines converted into it
+func buildConstraint(file *ast.File) string {
+	var plus []constraint.Expr
+	for _, cg := range file.Comments {
+		if cg.Pos() >= file.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if constraint.IsGoBuild(c.Text) {
+				if expr, err := constraint.Parse(c.Text); err == nil {
+					return expr.String()
+				}
+			}
+			if constraint.IsPlusBuild(c.Text) {
+				if expr, err := constraint.Parse(c.Text); err == nil {
+					plus = append(plus, expr)
+				}
+			}
+		}
+	}
+	if len(plus) == 0 {
+		return ""
+	}
+	expr := plus[0]
+	for _, x := range plus[1:] {
+		expr = &constraint.AndExpr{X: expr, Y: x}
+	}
+	return expr.String()
+}
@@ -0,0 +1,111 @@
+package patchwork
+
+import (
+	"go/build"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// TestPackageAndBuildConstraint
+func TestPackageAndBuildConstraint(t *testing.T) {
+	source := `
+package p
+
+func Hello() string {
+	return "hello"
+}
+`
+	sourceTest := `
+package p_test
+
+func Hello() string {
+	return "*test*"
+}
+`
+	sourceWindows := `//go:build windows
+
+package p
+
+func Hello() string {
+	return "*windows*"
+}
+`
+	sourcePlusBuild := `// +build linux darwin
+// +build amd64
+
+package p
+
+func Bye() string {
+	return "bye"
+}
+`
+
+	t.Run("mixed packages are rejected", func(t *testing.T) {
+		pw := NewPatchwork()
+		pw.MustParseFile("f0", source)
+		_, err := pw.ParseFile("f0_test", sourceTest)
+
+		var perr *PackageMismatchError
+		if !errors.As(err, &perr) {
+			t.Fatalf("expected PackageMismatchError, but %+v", err)
+		}
+		if perr.Package != "p_test" || perr.Expected != "p" {
+			t.Errorf("unexpected error %s", perr)
+		}
+	})
+
+	t.Run("mixed packages are allowed, but excluded from lookup", func(t *testing.T) {
+		pw := NewPatchwork(WithMixedPackages())
+		pf := pw.MustParseFile("f0", source)
+		pf2 := pw.MustParseFile("f0_test", sourceTest)
+		if !pf2.Excluded || pf2.Package != "p_test" {
+			t.Errorf("f0_test must be excluded, package=%s", pf2.Package)
+		}
+		if pf.Lookup("Hello").Object.Decl != pf.File.Scope.Lookup("Hello").Decl {
+			t.Error("Hello must be found in f0, not shadowed by f0_test")
+		}
+		if pf2.Lookup("Hello").Object.Decl != pf2.File.Scope.Lookup("Hello").Decl {
+			t.Error("excluded file can lookup its own declarations")
+		}
+	})
+
+	t.Run("build constraint", func(t *testing.T) {
+		type C struct {
+			msg      string
+			goos     string
+			goarch   string
+			filename string
+			source   string
+			excluded bool
+			expr     string
+		}
+		candidates := []C{
+			{msg: "go:build, not matched", goos: "linux", goarch: "amd64", filename: "f1", source: sourceWindows, excluded: true, expr: "windows"},
+			{msg: "go:build, matched", goos: "windows", goarch: "amd64", filename: "f1", source: sourceWindows, expr: "windows"},
+			{msg: "+build, matched", goos: "darwin", goarch: "amd64", filename: "f1", source: sourcePlusBuild, expr: "(linux || darwin) && amd64"},
+			{msg: "+build, not matched", goos: "linux", goarch: "arm64", filename: "f1", source: sourcePlusBuild, excluded: true, expr: "(linux || darwin) && amd64"},
+			{msg: "filename, not matched", goos: "linux", goarch: "amd64", filename: "f1_windows.go", source: source, excluded: true},
+		}
+		for _, c := range candidates {
+			c := c
+			t.Run(c.msg, func(t *testing.T) {
+				ctxt := build.Default
+				ctxt.GOOS = c.goos
+				ctxt.GOARCH = c.goarch
+				pw := NewPatchwork(WithBuildContext(&ctxt))
+				pw.MustParseFile("f0", "package p\n")
+				pf := pw.MustParseFile(c.filename, c.source)
+				if pf.Excluded != c.excluded {
+					t.Errorf("expected excluded=%v, but %v", c.excluded, pf.Excluded)
+				}
+				if pf.Constraint != c.expr {
+					t.Errorf("expected constraint %q, but %q", c.expr, pf.Constraint)
+				}
+				if found := pw.lookup.Lookup("Hello") != nil || pw.lookup.Lookup("Bye") != nil; found == c.excluded {
+					t.Errorf("expected found in lookup=%v, but %v", !c.excluded, found)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package patchwork
+
+import (
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action"
+	"github.com/podhmo/astknife/clone"
+	"github.com/podhmo/astknife/deps"
+	"github.com/podhmo/astknife/equal"
+	"github.com/podhmo/astknife/lookup"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// CopyMethods : append copies of all methods of from, as methods of to (e.g. to create a wrapper or mock).
+// if any method cannot be appended (e.g. already existed), nothing is appended
+func (pf *File) CopyMethods(from string, to string, options ...func(*action.Config)) ([]*lookup.Result, error) {
+	r := pf.lookup.Lookup(to)
+	if r == nil || r.Type != lookup.TypeToplevel {
+		return nil, errors.Errorf("type %s is not found", to)
+	}
+	methods := pf.lookup.AllMethods(from)
+	if len(methods) == 0 {
+		return nil, nil
+	}
+
+	pf.Begin()
+	var appended []*lookup.Result
+	for _, method := range methods {
+		mr := &lookup.Result{Type: lookup.TypeMethod, Object: r.Object, FuncDecl: clone.Method(method.FuncDecl, r.Object, to)}
+		if _, err := pf.Append(mr, options...); err != nil {
+			pf.Rollback()
+			return nil, err
+		}
+		appended = append(appended, mr)
+	}
+	return appended, pf.Commit()
+}
+
+// CopyConfig : options of CopyWithDependencies
+type CopyConfig struct {
+	// Rename : if set, the dependencies collided with the declarations in destination are renamed (otherwise, failed)
+	Rename func(name string) string
+	// AllMethods : if true, all methods of copied types are copied (otherwise, only the referred ones)
+	AllMethods bool
+}
+
+// WithRename : e.g. WithRename(func(name string) string { return name + "Copied" })
+func WithRename(rename func(name string) string) func(*CopyConfig) {
+	return func(c *CopyConfig) {
+		c.Rename = rename
+	}
+}
+
+// WithAllMethods : copy all methods of copied types (e.g. String() used via fmt.Stringer)
+func WithAllMethods() func(*CopyConfig) {
+	return func(c *CopyConfig) {
+		c.AllMethods = true
+	}
+}
+
+// CopyWithDependencies : append the declaration of src, with its transitive dependencies, and their imports.
+// the dependencies already defined identically in destination are skipped
+func (pf *File) CopyWithDependencies(src *File, name string, options ...func(*CopyConfig)) ([]*lookup.Result, error) {
+	c := &CopyConfig{}
+	for _, op := range options {
+		op(c)
+	}
+	r := src.lookup.Lookup(name)
+	if r == nil {
+		return nil, action.ErrReplacementNotFound
+	}
+
+	existed := func(r *lookup.Result) *lookup.Result {
+		if r.Type == lookup.TypeMethod {
+			return pf.lookup.Method(lookup.ReceiverName(r.FuncDecl), r.Name())
+		}
+		return pf.lookup.Toplevel(r.Name())
+	}
+	closureOptions := []func(*deps.Config){deps.WithSkip(func(r *lookup.Result) bool {
+		dr := existed(r)
+		return dr != nil && equal.Node(dr.Node(), r.Node())
+	})}
+	if c.AllMethods {
+		closureOptions = append(closureOptions, deps.WithAllMethods())
+	}
+	results, imports := deps.Closure(src.lookup, r, closureOptions...)
+
+	if c.Rename != nil {
+		renames := map[string]string{}
+		for _, r := range results[1:] {
+			if r.Type == lookup.TypeToplevel && existed(r) != nil {
+				renames[r.Name()] = c.Rename(r.Name())
+			}
+		}
+		if len(renames) > 0 {
+			results = deps.Rename(results, renames)
+		}
+	}
+
+	pf.Begin()
+	for _, r := range results {
+		if _, err := pf.Append(r); err != nil {
+			pf.Rollback()
+			return nil, err
+		}
+	}
+	for _, im := range imports {
+		astutil.AddNamedImport(pf.Fset, pf.File, im.Name, im.Path)
+	}
+	return results, pf.Commit()
+}
@@ -0,0 +1,273 @@
+package patchwork
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action/failure"
+)
+
+// TestCopyMethods
+func TestCopyMethods(t *testing.T) {
+	source := `
+package p
+
+type S struct {
+	S string
+}
+
+type S2 struct {
+	S string
+}
+
+// New : constructor
+func (s *S) New() *S {
+	return &S{S: s.S}
+}
+
+func (s S) Name() string {
+	var S = "shadowed"
+	return S + s.S
+}
+`
+	source2 := `
+package p
+
+type S3 struct {
+	S string
+}
+
+func (s *S3) New() *S3 {
+	return nil
+}
+`
+
+	t.Run("copied", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", source)
+		original := pf.Lookup("S").Object
+
+		appended, err := pf.CopyMethods("S", "S2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(appended) != 2 {
+			t.Fatalf("expected 2 methods are appended, but %d", len(appended))
+		}
+
+		var b bytes.Buffer
+		if err := pf.FprintCode(&b); err != nil {
+			t.Fatal(err)
+		}
+		code := b.String()
+		for _, expected := range []string{
+			"func (s *S2) New() *S2 {\n\treturn &S2{S: s.S}\n}",
+			"func (s S2) Name() string {\n\tvar S = \"shadowed\"\n\treturn S + s.S\n}",
+			"func (s *S) New() *S {\n\treturn &S{S: s.S}\n}",
+		} {
+			if !strings.Contains(code, expected) {
+				t.Errorf("expected %q is contained, but\n%s", expected, code)
+			}
+		}
+		if pf.Lookup("S").Object != original || len(pf.LookupAllMethods("S")) != 2 {
+			t.Error("the methods of source type must not be modified")
+		}
+		if err := pf.Verify(nil); err != nil {
+			t.Errorf("copied code must be type-checked, but %s", err)
+		}
+	})
+
+	t.Run("already existed, rolled back", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", source)
+		NewPatchwork(WithFileSet(pf.Fset), WithLookup(pf.lookup)).MustParseFile("f1", source2)
+		_, err := pf.CopyMethods("S", "S3")
+
+		var aerr *failure.AlreadyExistsError
+		if !errors.As(err, &aerr) {
+			t.Fatalf("expected AlreadyExistsError, but %+v", err)
+		}
+		if len(pf.LookupAllMethods("S3")) != 1 {
+			t.Errorf("nothing must be appended, but %d methods", len(pf.LookupAllMethods("S3")))
+		}
+	})
+}
+
+// TestCopyWithDependencies
+func TestCopyWithDependencies(t *testing.T) {
+	source := `
+package q
+
+import (
+	"fmt"
+	str "strings"
+)
+
+type item struct {
+	name string
+}
+
+func (i item) String() string {
+	return fmt.Sprintf("item(%s)", i.name)
+}
+
+const sep = ", "
+
+func helper(items []item) string {
+	var xs []string
+	for _, x := range items {
+		xs = append(xs, x.String())
+	}
+	return str.Join(xs, sep)
+}
+
+func Format(names ...string) string {
+	items := make([]item, len(names))
+	for i, name := range names {
+		items[i] = item{name: name}
+	}
+	return helper(items)
+}
+
+func unused() {}
+`
+	collided := `
+package p
+
+const sep = "; "
+
+func helper() {}
+`
+
+	t.Run("copied", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", "package p\n\nconst sep = \", \"\n")
+		src := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source)
+
+		results, err := pf.CopyWithDependencies(src, "Format")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, r := range results {
+			names = append(names, r.FullName())
+		}
+		if expected := "Format,item,helper,item.String"; strings.Join(names, ",") != expected {
+			t.Errorf("expected %s, but %s (identical sep is skipped)", expected, strings.Join(names, ","))
+		}
+
+		code, err := printerString(pf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range []string{`"fmt"`, `str "strings"`} {
+			if !strings.Contains(code, expected) {
+				t.Errorf("expected import %s is contained, but\n%s", expected, code)
+			}
+		}
+		if strings.Contains(code, "unused") {
+			t.Errorf("unused must not be copied, but\n%s", code)
+		}
+		if err := pf.Verify(nil); err != nil {
+			t.Errorf("copied code must be type-checked, but %s", err)
+		}
+	})
+
+	t.Run("collided, failed", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", collided)
+		src := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source)
+
+		_, err := pf.CopyWithDependencies(src, "Format")
+		var aerr *failure.AlreadyExistsError
+		if !errors.As(err, &aerr) {
+			t.Fatalf("expected AlreadyExistsError, but %+v", err)
+		}
+		if pf.Lookup("Format") != nil {
+			t.Error("nothing must be copied")
+		}
+	})
+
+	t.Run("collided, renamed", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", collided)
+		src := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source)
+
+		_, err := pf.CopyWithDependencies(src, "Format", WithRename(func(name string) string { return name + "Copied" }))
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, err := printerString(pf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range []string{
+			"return helperCopied(items)",
+			"func helperCopied(items []item) string {",
+			"return str.Join(xs, sepCopied)",
+			"const sepCopied = \", \"",
+		} {
+			if !strings.Contains(code, expected) {
+				t.Errorf("expected %q is contained, but\n%s", expected, code)
+			}
+		}
+		if err := pf.Verify(nil); err != nil {
+			t.Errorf("copied code must be type-checked, but %s", err)
+		}
+
+		// source is not modified
+		if code, _ := printerString(src); strings.Contains(code, "Copied") {
+			t.Errorf("source must not be modified, but\n%s", code)
+		}
+	})
+
+	t.Run("methods, only referred", func(t *testing.T) {
+		methods := `
+package q
+
+type item struct {
+	name string
+}
+
+func (i item) String() string {
+	return "item(" + i.name + ")"
+}
+
+func (i item) Name() string {
+	return i.name
+}
+
+type namer interface {
+	Name() string
+}
+
+func New(name string) namer {
+	return item{name: name}
+}
+`
+		candidates := []struct {
+			msg      string
+			options  []func(*CopyConfig)
+			expected string
+		}{
+			{msg: "default", expected: "New,namer,item,item.Name"},
+			{msg: "all methods", options: []func(*CopyConfig){WithAllMethods()}, expected: "New,namer,item,item.String,item.Name"},
+		}
+		for _, c := range candidates {
+			pf := NewPatchwork().MustParseFile("f0", "package p\n")
+			src := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", methods)
+
+			results, err := pf.CopyWithDependencies(src, "New", c.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, r := range results {
+				names = append(names, r.FullName())
+			}
+			if strings.Join(names, ",") != c.expected {
+				t.Errorf("%s: expected %s, but %s", c.msg, c.expected, strings.Join(names, ","))
+			}
+			if err := pf.Verify(nil); err != nil {
+				t.Errorf("%s: copied code must be type-checked, but %s", c.msg, err)
+			}
+		}
+	})
+}
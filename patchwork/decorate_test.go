@@ -0,0 +1,120 @@
+package patchwork
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action/failure"
+	"github.com/podhmo/astknife/action/wrap"
+)
+
+// TestDecorate
+func TestDecorate(t *testing.T) {
+	source := `
+package p
+
+import "log"
+
+type S struct{}
+
+func Hello(name string, _ int, args ...string) string {
+	return "hello " + name
+}
+
+func (S) Bye() {
+	log.Println("bye")
+}
+`
+	source2 := `
+package p
+
+import "log"
+
+func trace() {
+	log.Println("start")
+}
+`
+	type C struct {
+		msg      string
+		name     string
+		mode     wrap.Mode
+		contains []string
+	}
+
+	candidates := []C{
+		{
+			msg:      "prologue",
+			name:     "Hello",
+			mode:     wrap.ModePrologue,
+			contains: []string{"func Hello(name string, _ int, args ...string) string {\n\tlog.Println(\"start\")\n\treturn \"hello \" + name\n}"},
+		},
+		{
+			msg:      "epilogue",
+			name:     "S.Bye",
+			mode:     wrap.ModeEpilogue,
+			contains: []string{"func (S) Bye() {\n\tdefer func() {\n\t\tlog.Println(\"start\")\n\t}()\n\tlog.Println(\"bye\")\n}"},
+		},
+		{
+			msg:  "inner, function",
+			name: "Hello",
+			mode: wrap.ModeInner,
+			contains: []string{
+				"func HelloInner(name string, _ int, args ...string) string {\n\treturn \"hello \" + name\n}",
+				"func Hello(name string, p1 int, args ...string) string {\n\tlog.Println(\"start\")\n\treturn HelloInner(name, p1, args...)\n}",
+			},
+		},
+		{
+			msg:  "inner, method",
+			name: "S.Bye",
+			mode: wrap.ModeInner,
+			contains: []string{
+				"func (S) ByeInner() {",
+				"func (recv S) Bye() {\n\tlog.Println(\"start\")\n\trecv.ByeInner()\n}",
+			},
+		},
+	}
+
+	for _, c := range candidates {
+		c := c
+		t.Run(c.msg, func(t *testing.T) {
+			pf := NewPatchwork().MustParseFile("f0", source)
+			tmpl := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2).Lookup("trace")
+
+			ok, err := pf.Decorate(pf.Lookup(c.name), tmpl, c.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Fatal("must be decorated")
+			}
+
+			var b bytes.Buffer
+			if err := pf.FprintCode(&b); err != nil {
+				t.Fatal(err)
+			}
+			for _, expected := range c.contains {
+				if !strings.Contains(b.String(), expected) {
+					t.Errorf("expected %q is contained, but\n%s", expected, b.String())
+				}
+			}
+			if err := pf.Verify(nil); err != nil {
+				t.Errorf("decorated code must be type-checked, but %s", err)
+			}
+		})
+	}
+
+	t.Run("inner, already existed", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", source)
+		tmpl := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2).Lookup("trace")
+		if _, err := pf.Decorate(pf.Lookup("Hello"), tmpl, wrap.ModeInner); err != nil {
+			t.Fatal(err)
+		}
+		_, err := pf.Decorate(pf.Lookup("Hello"), tmpl, wrap.ModeInner)
+		var aerr *failure.AlreadyExistsError
+		if !errors.As(err, &aerr) {
+			t.Fatalf("expected AlreadyExistsError, but %+v", err)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package patchwork
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action"
+	"github.com/podhmo/astknife/fingerprint"
+	"github.com/podhmo/astknife/lookup"
+)
+
+// TestFingerprint
+func TestFingerprint(t *testing.T) {
+	source := `
+package p
+func Hello() string {
+	return "hello"
+}
+`
+	source2 := `
+package p
+func Hello() string {
+	return "hello!"
+}
+func Bye() string {
+	return "bye"
+}
+`
+	pf := NewPatchwork().MustParseFile("f0", source)
+	pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2).Wrap(pf.Patchwork)
+	m := fingerprint.NewManifest()
+
+	for _, name := range []string{"Hello", "Bye"} {
+		ok, err := pf.AppendOrReplace(pf1.Lookup(name), action.WithManifest(m))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Fatalf("%s must be applied", name)
+		}
+	}
+
+	// re-run
+	for _, name := range []string{"Hello", "Bye"} {
+		ok, err := pf.AppendOrReplace(pf1.Lookup(name), action.WithManifest(m))
+		if ok || errors.Cause(err) != action.ErrAlreadyApplied {
+			t.Fatalf("%s: already applied is expected, but got (%v, %v)", name, ok, err)
+		}
+	}
+
+	// drifted
+	pf2 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f2", "package p\nfunc Bye() string { return \"drifted\" }\n").Wrap(pf.Patchwork)
+	if _, err := pf.Replace(pf2.Lookup("Bye")); err != nil {
+		t.Fatal(err)
+	}
+	current, _ := lookup.FindInFile(pf.File, "Bye")
+	if status := m.Status("Bye", current, pf1.Lookup("Bye").Node()); status != fingerprint.StatusDrifted {
+		t.Fatalf("drifted is expected, but got %q", status)
+	}
+
+	ok, err := pf.AppendOrReplace(pf1.Lookup("Bye"), action.WithManifest(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("drifted declaration must be applied again")
+	}
+}
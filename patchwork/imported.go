@@ -0,0 +1,45 @@
+package patchwork
+
+import (
+	"go/ast"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/lookup"
+	"github.com/podhmo/astknife/resolve"
+)
+
+// LookupImported : lookup the declaration in other package, by import path (e.g. "github.com/foo/bar.Type.Method"),
+// or by the package name imported in the file (e.g. "bar.Type"). the package is resolved offline, from the directory of the file
+func (pf *File) LookupImported(name string, options ...func(*resolve.Config)) (*lookup.Result, error) {
+	from := filepath.Dir(pf.Fset.Position(pf.File.Package).Filename)
+	path, rest, ok := resolve.SplitName(pf.File, name, from)
+	if !ok {
+		return nil, errors.Errorf("%s is not qualified by the imported package", name)
+	}
+
+	if pf.Context != nil {
+		options = append([]func(*resolve.Config){resolve.WithContext(pf.Context)}, options...)
+	}
+	dir, err := resolve.Dir(path, from, options...)
+	if err != nil {
+		return nil, err
+	}
+	files, ok := pf.packages[dir]
+	if !ok {
+		files, err = resolve.PackageInDir(pf.Fset, dir, options...)
+		if err != nil {
+			return nil, err
+		}
+		if pf.packages == nil {
+			pf.packages = map[string][]*ast.File{}
+		}
+		pf.packages[dir] = files
+	}
+
+	r := lookup.New(files...).Lookup(rest)
+	if r == nil {
+		return nil, errors.Errorf("%s is not found in package %s", rest, path)
+	}
+	return r, nil
+}
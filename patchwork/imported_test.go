@@ -0,0 +1,116 @@
+package patchwork
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/resolve"
+)
+
+// TestLookupImported
+func TestLookupImported(t *testing.T) {
+	dir, err := ioutil.TempDir("", "astknife")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"go.mod":                          "module example.com/m\n\nrequire (\n\texample.org/v v1.0.0\n\tgopkg.in/yaml.v3 v3.0.1\n)\n",
+		"sub/sub.go":                      "package sub\n\ntype Type struct{}\n\nfunc (t *Type) Method() string {\n\treturn \"sub\"\n}\n",
+		"sub/sub_test.go":                 "package sub\n\nfunc Method() {}\n",
+		"vendor/example.org/v/v.go":       "package v\n\nfunc Hello() string {\n\treturn \"vendored\"\n}\n",
+		"main.go":                         "package main\n\nimport (\n\t\"example.com/m/sub\"\n\tvv \"example.org/v\"\n\t\"strings\"\n)\n\nvar _ = sub.Type{}\nvar _ = vv.Hello\nvar _ = strings.Builder{}\n",
+		"vendor/example.org/v/v2.go":      "// +build ignore\n\npackage v\n\nfunc Hello() string {\n\treturn \"ignored\"\n}\n",
+		"vendor/gopkg.in/yaml.v3/yaml.go": "package yaml\n\ntype Node struct{}\n",
+		"other/go.mod":                    "module example.com/other\n",
+		"other/vendor/example.org/v/v.go": "package v\n\nfunc Hello() string {\n\treturn \"vendored in other\"\n}\n",
+		"other/main.go":                   "package main\n\nimport vv \"example.org/v\"\n\nvar _ = vv.Hello\n",
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pf, err := NewPatchwork().ParseFile(filepath.Join(dir, "main.go"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type C struct {
+		msg      string
+		name     string
+		fullname string
+		file     string
+	}
+	candidates := []C{
+		{msg: "main module, by import path", name: "example.com/m/sub.Type.Method", fullname: "Type.Method", file: "sub.go"},
+		{msg: "main module, by package name", name: "sub.Type", fullname: "Type", file: "sub.go"},
+		{msg: "vendor, by named import", name: "vv.Hello", fullname: "Hello", file: "v.go"},
+		{msg: "GOROOT", name: "strings.Builder", fullname: "Builder", file: "builder.go"},
+		{msg: "import path including dot", name: "gopkg.in/yaml.v3.Node", fullname: "Node", file: "yaml.go"},
+	}
+	for _, c := range candidates {
+		c := c
+		t.Run(c.msg, func(t *testing.T) {
+			r, err := pf.LookupImported(c.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.FullName() != c.fullname {
+				t.Errorf("expected %s, but %s", c.fullname, r.FullName())
+			}
+			if filename := pf.Fset.Position(r.Node().Pos()).Filename; filepath.Base(filename) != c.file {
+				t.Errorf("expected found in %s, but %s", c.file, filename)
+			}
+		})
+	}
+
+	t.Run("appended", func(t *testing.T) {
+		r, err := pf.LookupImported("vv.Hello")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pf.Append(r); err != nil {
+			t.Fatal(err)
+		}
+		code, err := printerString(pf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(code, "return \"vendored\"") {
+			t.Errorf("vendored Hello must be appended, but\n%s", code)
+		}
+	})
+
+	t.Run("same import path, from other module", func(t *testing.T) {
+		other, err := pf.ParseFile(filepath.Join(dir, "other", "main.go"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := other.LookupImported("vv.Hello")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filename := pf.Fset.Position(r.Node().Pos()).Filename; !strings.Contains(filename, filepath.Join("other", "vendor")) {
+			t.Errorf("expected found in other/vendor, but %s", filename)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := pf.LookupImported("example.com/m/missing.Type")
+		var nerr *resolve.PackageNotFoundError
+		if !errors.As(err, &nerr) {
+			t.Fatalf("expected PackageNotFoundError, but %+v", err)
+		}
+	})
+}
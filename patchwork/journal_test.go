@@ -0,0 +1,88 @@
+package patchwork
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action"
+	"github.com/podhmo/astknife/journal"
+)
+
+// TestJournal
+func TestJournal(t *testing.T) {
+	source := `
+package p
+
+func Hello() string {
+	return "hello"
+}
+`
+	source2 := `
+package p
+
+func Hello() string {
+	return "*replaced*"
+}
+`
+
+	t.Run("undo", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", source)
+		pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2)
+		before, err := printerString(pf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		j := &journal.Journal{}
+		if _, err := pf.Replace(pf1.Lookup("Hello"), action.WithJournal(pf.Fset, j)); err != nil {
+			t.Fatal(err)
+		}
+		if len(j.Entries) != 1 {
+			t.Fatalf("expected 1 entry is journalized, but %d", len(j.Entries))
+		}
+		if err := pf.Undo(j); err != nil {
+			t.Fatal(err)
+		}
+		if after, _ := printerString(pf); after != before {
+			t.Errorf("expected reverted, but\n%s", after)
+		}
+	})
+
+	t.Run("without FileSet", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", source)
+		pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2)
+
+		j := &journal.Journal{}
+		_, err := action.Replace(pf.lookup, pf.File, pf1.Lookup("Hello"), func(c *action.Config) { c.Journal = j })
+		if errors.Cause(err) != action.ErrFileSetRequired {
+			t.Fatalf("expected ErrFileSetRequired, but %v", err)
+		}
+	})
+	t.Run("undo, duplicated in other file", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", "package p\n")
+		psibling := pf.MustParseFile("sibling", source)
+		pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2)
+		before, err := printerString(psibling)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		j := &journal.Journal{}
+		if _, err := pf.Append(pf1.Lookup("Hello"), action.WithReplaceDuplicates(), action.WithJournal(pf.Fset, j)); err != nil {
+			t.Fatal(err)
+		}
+		if len(j.Entries) != 1 || j.Entries[0].File != "sibling" {
+			t.Fatalf("expected 1 entry of sibling is journalized, but %+v", j.Entries)
+		}
+
+		if err := pf.Undo(j); err != nil {
+			t.Fatal(err)
+		}
+		if err := psibling.Undo(j); err != nil {
+			t.Fatal(err)
+		}
+		if after, _ := printerString(psibling); after != before {
+			t.Errorf("expected reverted, but\n%s", after)
+		}
+	})
+}
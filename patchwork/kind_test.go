@@ -0,0 +1,142 @@
+package patchwork
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action"
+	"github.com/podhmo/astknife/action/failure"
+)
+
+// TestReplaceKindMismatch
+func TestReplaceKindMismatch(t *testing.T) {
+	source := `
+package p
+type S struct {}
+type T = S
+func Hello() string {
+	return "hello"
+}
+var Bye = func() string {
+	return "bye"
+}
+var Foo, Bar = func() {}, func() {}
+`
+	type C struct {
+		source2  string
+		name     string
+		msg      string
+		policy   action.KindPolicy
+		mismatch bool
+		contains string
+	}
+
+	candidates := []C{
+		{
+			msg:      "type with alias, error",
+			name:     "S",
+			source2:  "package p\ntype S = T\n",
+			mismatch: true,
+		},
+		{
+			msg:      "type with alias, allowed",
+			name:     "S",
+			source2:  "package p\ntype S = T\n",
+			policy:   action.KindPolicyAlias,
+			contains: "type S = T",
+		},
+		{
+			msg:      "alias with type, error",
+			name:     "T",
+			source2:  "package p\ntype T struct {}\n",
+			mismatch: true,
+		},
+		{
+			msg:      "alias with type, allowed",
+			name:     "T",
+			source2:  "package p\ntype T struct {}\n",
+			policy:   action.KindPolicyAlias,
+			contains: "type T struct",
+		},
+		{
+			msg:      "func with var, error",
+			name:     "Hello",
+			source2:  "package p\nvar Hello = func() string { return \"*var*\" }\n",
+			mismatch: true,
+		},
+		{
+			msg:      "func with var, converted",
+			name:     "Hello",
+			source2:  "package p\nvar Hello = func() string { return \"*var*\" }\n",
+			policy:   action.KindPolicyConvert,
+			contains: "var Hello = func",
+		},
+		{
+			msg:      "func with var not of func type, error",
+			name:     "Hello",
+			source2:  "package p\nvar Hello = \"*var*\"\n",
+			policy:   action.KindPolicyConvert,
+			mismatch: true,
+		},
+		{
+			msg:      "func with var of func type, converted",
+			name:     "Hello",
+			source2:  "package p\nvar Hello func() string = nil\n",
+			policy:   action.KindPolicyConvert,
+			contains: "var Hello func() string = nil",
+		},
+		{
+			msg:      "var with func, converted",
+			name:     "Bye",
+			source2:  "package p\nfunc Bye() string { return \"*func*\" }\n",
+			policy:   action.KindPolicyConvert,
+			contains: "var Bye = func() string",
+		},
+		{
+			msg:      "var with func, multiple names, error",
+			name:     "Foo",
+			source2:  "package p\nfunc Foo() {}\n",
+			policy:   action.KindPolicyConvert,
+			mismatch: true,
+		},
+	}
+
+	for _, c := range candidates {
+		c := c
+		t.Run(c.msg, func(t *testing.T) {
+			pf := NewPatchwork().MustParseFile("f0", source)
+			pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", c.source2)
+
+			var options []func(*action.Config)
+			if c.policy != "" {
+				options = append(options, action.WithKindPolicy(c.policy))
+			}
+			ok, err := pf.Replace(pf1.Wrap(pf.Patchwork).Lookup(c.name), options...)
+			if c.mismatch {
+				t.Logf("should error %s", err)
+				var target *failure.KindMismatchError
+				if !errors.As(err, &target) {
+					t.Fatalf("KindMismatchError is expected, but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Fatal("must be replaced")
+			}
+
+			var b bytes.Buffer
+			if err := pf.FprintCode(&b); err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("output\n%s\n", b.String())
+			if !strings.Contains(b.String(), c.contains) {
+				t.Errorf("should contain %q", c.contains)
+			}
+		})
+	}
+}
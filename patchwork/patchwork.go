@@ -2,16 +2,30 @@ package patchwork
 
 import (
 	"go/ast"
+	"go/build"
 	"go/parser"
 	"go/token"
+	"io"
+	"io/ioutil"
 
+	"github.com/pkg/errors"
 	"github.com/podhmo/astknife/lookup"
+	"github.com/podhmo/astknife/placeholder"
 )
 
 // Patchwork : (todo rename)
 type Patchwork struct {
 	Fset   *token.FileSet
 	lookup *lookup.Lookup
+
+	// Context : if set, the files not matched with its build constraints are excluded from lookup
+	Context *build.Context
+	// Package : package name of the files in lookup (if empty, the package of the first file is used)
+	Package string
+	// MixedPackages : if true, the files of other packages are accepted, but excluded from lookup
+	MixedPackages bool
+
+	packages map[string][]*ast.File // cache of LookupImported, directory -> files
 }
 
 // NewPatchwork :
@@ -43,19 +57,43 @@ func WithLookup(lookup *lookup.Lookup) func(*Patchwork) {
 	}
 }
 
+// WithBuildContext : the files not matched with the build constraints are excluded from lookup (e.g. `//go:build windows`)
+func WithBuildContext(ctxt *build.Context) func(*Patchwork) {
+	return func(pw *Patchwork) {
+		pw.Context = ctxt
+	}
+}
+
+// WithPackage : package name of the files in lookup
+func WithPackage(name string) func(*Patchwork) {
+	return func(pw *Patchwork) {
+		pw.Package = name
+	}
+}
+
+// WithMixedPackages : the files of other packages are accepted (e.g. `package p_test`), but excluded from lookup
+func WithMixedPackages() func(*Patchwork) {
+	return func(pw *Patchwork) {
+		pw.MixedPackages = true
+	}
+}
+
 // ParseFile :
 func (pw *Patchwork) ParseFile(filename string, source interface{}) (*File, error) {
 	file, err := parser.ParseFile(pw.Fset, filename, source, parser.ParseComments)
-	pw.lookup.Files = append(pw.lookup.Files, file)
-	f := &File{Patchwork: pw, File: file}
-	return f, err
+	if file == nil {
+		return nil, err
+	}
+	f, rerr := pw.register(filename, file)
+	if err != nil {
+		return f, err
+	}
+	return f, rerr
 }
 
 // ParseAST :
 func (pw *Patchwork) ParseAST(filename string, file *ast.File) (*File, error) {
-	pw.lookup.Files = append(pw.lookup.Files, file)
-	f := &File{Patchwork: pw, File: file}
-	return f, nil
+	return pw.register(filename, file)
 }
 
 // MustParseFile :
@@ -66,3 +104,34 @@ func (pw *Patchwork) MustParseFile(filename string, source interface{}) *File {
 	}
 	return f
 }
+
+// ParseTemplate : parse the template source, whose placeholders (e.g. `__Type__`) are substituted by bindings
+func (pw *Patchwork) ParseTemplate(filename string, source interface{}, bindings map[string]string) (*File, error) {
+	var src []byte
+	switch s := source.(type) {
+	case string:
+		src = []byte(s)
+	case []byte:
+		src = s
+	case io.Reader:
+		b, err := ioutil.ReadAll(s)
+		if err != nil {
+			return nil, err
+		}
+		src = b
+	case nil:
+		b, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		src = b
+	default:
+		return nil, errors.Errorf("invalid source type %T", source)
+	}
+
+	expanded, err := placeholder.Expand(filename, src, bindings)
+	if err != nil {
+		return nil, err
+	}
+	return pw.ParseFile(filename, expanded)
+}
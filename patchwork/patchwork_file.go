@@ -2,9 +2,12 @@ package patchwork
 
 import (
 	"go/ast"
+	"go/token"
 	"io"
 
 	"github.com/podhmo/astknife/action"
+	"github.com/podhmo/astknife/action/wrap"
+	"github.com/podhmo/astknife/journal"
 	"github.com/podhmo/astknife/lookup"
 	"github.com/podhmo/astknife/printer"
 )
@@ -13,6 +16,14 @@ import (
 type File struct {
 	*Patchwork
 	File *ast.File
+
+	Package    string // package name
+	Constraint string // build constraint (e.g. "linux && amd64"), empty if not constrained
+	Excluded   bool   // excluded from lookup, by package or build constraint
+
+	origin *token.FileSet // FileSet which the file is parsed with (kept by Wrap)
+
+	snapshots []*Snapshot // for transaction
 }
 
 // FprintCode :
@@ -32,37 +43,102 @@ func (pf *File) PrintAST() error {
 
 // Lookup :
 func (pf *File) Lookup(name string) *lookup.Result {
-	return pf.lookup.Lookup(name)
+	return pf.withFileSet(pf.lookup.Lookup(name))
 }
 
 // LookupAllMethods :
 func (pf *File) LookupAllMethods(obname string) []*lookup.Result {
 	// todo: xxx
-	return pf.lookup.AllMethods(obname)
+	methods := pf.lookup.AllMethods(obname)
+	for _, r := range methods {
+		pf.withFileSet(r)
+	}
+	return methods
+}
+
+// withFileSet : set the FileSet of the file declaring r (the wrapped file may be parsed with other FileSet)
+func (pf *File) withFileSet(r *lookup.Result) *lookup.Result {
+	if r == nil || r.Fset != nil {
+		return r
+	}
+	r.Fset = pf.Fset
+	if pf.origin == nil {
+		return r
+	}
+	node := r.Node()
+	for _, decl := range pf.File.Decls {
+		if decl == node {
+			r.Fset = pf.origin
+			return r
+		}
+		if decl, ok := decl.(*ast.GenDecl); ok {
+			for _, spec := range decl.Specs {
+				if spec == node {
+					r.Fset = pf.origin
+					return r
+				}
+			}
+		}
+	}
+	return r
 }
 
 // Append :
-func (pf *File) Append(r *lookup.Result) (ok bool, err error) {
-	return action.Append(pf.lookup, pf.File, r)
+func (pf *File) Append(r *lookup.Result, options ...func(*action.Config)) (ok bool, err error) {
+	return action.Append(pf.lookup, pf.File, r, pf.actionOptions(options)...)
 }
 
 // Replace :
-func (pf *File) Replace(r *lookup.Result) (ok bool, err error) {
-	return action.Replace(pf.lookup, pf.File, r)
+func (pf *File) Replace(r *lookup.Result, options ...func(*action.Config)) (ok bool, err error) {
+	return action.Replace(pf.lookup, pf.File, r, pf.actionOptions(options)...)
 }
 
 // AppendOrReplace : upsert
-func (pf *File) AppendOrReplace(r *lookup.Result) (ok bool, err error) {
-	return action.AppendOrReplace(pf.lookup, pf.File, r)
+func (pf *File) AppendOrReplace(r *lookup.Result, options ...func(*action.Config)) (ok bool, err error) {
+	return action.AppendOrReplace(pf.lookup, pf.File, r, pf.actionOptions(options)...)
+}
+
+// Delete :
+func (pf *File) Delete(r *lookup.Result, options ...func(*action.Config)) (ok bool, err error) {
+	return action.Delete(pf.lookup, pf.File, r, pf.actionOptions(options)...)
+}
+
+// Decorate : wrap the function or method, by the body of template function (see wrap.Mode)
+func (pf *File) Decorate(r *lookup.Result, tmpl *lookup.Result, mode wrap.Mode, options ...func(*action.Config)) (ok bool, err error) {
+	return action.Wrap(pf.lookup, pf.File, r, tmpl, mode, pf.actionOptions(options)...)
+}
+
+// Undo : revert the journaled changes (all-or-nothing)
+func (pf *File) Undo(j *journal.Journal) error {
+	pf.Begin()
+	if err := journal.Undo(pf.Fset, pf.File, j); err != nil {
+		pf.Rollback()
+		return err
+	}
+	return pf.Commit()
+}
+
+func (pf *File) actionOptions(options []func(*action.Config)) []func(*action.Config) {
+	options = append([]func(*action.Config){action.WithFileSet(pf.Fset)}, options...)
+	if len(pf.snapshots) > 0 {
+		// other files are not restored by Rollback()
+		options = append(options, action.WithDestinationOnly())
+	}
+	return options
 }
 
 // Wrap : xxx
 func (pf *File) Wrap(pw *Patchwork) *File {
+	origin := pf.origin
+	if origin == nil {
+		origin = pf.Fset
+	}
 	return &File{
 		Patchwork: &Patchwork{
 			Fset:   pw.Fset,
 			lookup: pw.lookup.With(pf.File),
 		},
-		File: pf.File,
+		File:   pf.File,
+		origin: origin,
 	}
 }
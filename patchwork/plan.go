@@ -0,0 +1,172 @@
+package patchwork
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/token"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action"
+	"github.com/podhmo/astknife/diff"
+	"github.com/podhmo/astknife/lookup"
+	"github.com/podhmo/astknife/printer"
+)
+
+// PlanAction : the action which the operation would take
+type PlanAction string
+
+const (
+	// PlanAppend :
+	PlanAppend = PlanAction("append")
+	// PlanReplace :
+	PlanReplace = PlanAction("replace")
+	// PlanDelete :
+	PlanDelete = PlanAction("delete")
+	// PlanNoop : no effect (e.g. target not found, unchanged)
+	PlanNoop = PlanAction("no-op")
+	// PlanConflict : the operation would fail (e.g. already existed, protected)
+	PlanConflict = PlanAction("conflict")
+)
+
+// Step : planned operation
+type Step struct {
+	Type    OpType     `json:"type"`
+	Name    string     `json:"name"`
+	Action  PlanAction `json:"action"`
+	Message string     `json:"message,omitempty"`
+
+	Source *token.Position `json:"source,omitempty"` // position of replacement
+	Target *token.Position `json:"target,omitempty"` // position of target in destination
+	Diff   string          `json:"diff,omitempty"`   // preview
+}
+
+// Plan : serializable plan, computed by File.Plan()
+type Plan struct {
+	Steps []*Step `json:"steps"`
+}
+
+// FprintJSON :
+func (p *Plan) FprintJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(p)
+}
+
+// ReadPlan : read the plan written by FprintJSON
+func ReadPlan(r io.Reader) (*Plan, error) {
+	var p Plan
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, errors.Wrap(err, "read plan")
+	}
+	return &p, nil
+}
+
+// Plan : compute the plan without mutating anything (operations are applied in a transaction, and rolled back).
+// the journal and manifest in action options are not recorded
+func (pf *File) Plan(ops []Op, options ...func(*ApplyConfig)) (*Plan, error) {
+	c := &ApplyConfig{}
+	for _, op := range options {
+		op(c)
+	}
+	actionOptions := append(append([]func(*action.Config){}, c.ActionOptions...), action.WithDryRun())
+
+	pf.Begin()
+	defer pf.Rollback()
+
+	plan := &Plan{}
+	for _, op := range ops {
+		step := &Step{Type: op.Type, Name: op.Name}
+		plan.Steps = append(plan.Steps, step)
+		if op.Result == nil {
+			step.Action = PlanNoop
+			step.Message = action.ErrReplacementNotFound.Error()
+			continue
+		}
+		step.Name = op.Result.FullName()
+
+		var before, after string
+		target := pf.target(op.Result)
+		if target != nil {
+			step.Target = position(pf.Fset, target.Pos())
+			s, err := printer.SprintCode(pf.Fset, target)
+			if err != nil {
+				return nil, err
+			}
+			before = s
+		}
+		if op.Type != OpDelete {
+			node := op.Result.Node()
+			fset := pf.fileSetOf(op.Result)
+			step.Source = position(fset, node.Pos())
+			s, err := printer.SprintCode(fset, node)
+			if err != nil {
+				return nil, err
+			}
+			after = s
+		}
+
+		ok, err := pf.apply(op, actionOptions)
+		switch {
+		case err == nil && ok:
+			switch {
+			case op.Type == OpDelete:
+				step.Action = PlanDelete
+			case target != nil:
+				step.Action = PlanReplace
+			default:
+				step.Action = PlanAppend
+			}
+			step.Diff = diff.Lines(before, after)
+		case err == nil || action.IsNoEffect(err):
+			step.Action = PlanNoop
+			if err != nil {
+				step.Message = err.Error()
+			}
+		default:
+			step.Action = PlanConflict
+			step.Message = err.Error()
+			step.Diff = diff.Lines(before, after)
+		}
+	}
+	return plan, nil
+}
+
+// ApplyPlan : apply the plan computed before. replacements are looked up from src by name
+func (pf *File) ApplyPlan(plan *Plan, src *File, options ...func(*ApplyConfig)) (*Report, error) {
+	wrapped := src.Wrap(pf.Patchwork)
+	var ops []Op
+	for _, step := range plan.Steps {
+		switch step.Action {
+		case PlanAppend, PlanReplace:
+			ops = append(ops, Op{Type: step.Type, Name: step.Name, Result: wrapped.Lookup(step.Name)})
+		case PlanDelete:
+			ops = append(ops, Op{Type: step.Type, Name: step.Name, Result: pf.Lookup(step.Name)})
+		}
+	}
+	return pf.ApplyAll(ops, options...)
+}
+
+// target : the declaration in destination, corresponding to r
+func (pf *File) target(r *lookup.Result) ast.Node {
+	switch r.Type {
+	case lookup.TypeToplevel:
+		ob := pf.File.Scope.Lookup(r.Name())
+		if ob == nil {
+			return nil
+		}
+		node, _ := ob.Decl.(ast.Node)
+		return node
+	case lookup.TypeMethod:
+		if r.Object == nil {
+			return nil
+		}
+		dr := pf.lookup.MethodByObject(r.Object, r.Name())
+		if dr == nil {
+			return nil
+		}
+		return dr.FuncDecl
+	default:
+		return nil
+	}
+}
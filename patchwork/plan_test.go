@@ -0,0 +1,140 @@
+package patchwork
+
+import (
+	"bytes"
+	"go/ast"
+	"strings"
+	"testing"
+
+	"github.com/podhmo/astknife/action"
+	"github.com/podhmo/astknife/fingerprint"
+	"github.com/podhmo/astknife/journal"
+	"github.com/podhmo/astknife/lookup"
+)
+
+// TestPlan
+func TestPlan(t *testing.T) {
+	source := `
+package p
+type S struct {}
+func Hello() string {
+	return "hello"
+}
+`
+	source2 := `
+package p
+type S struct {}
+type S2 struct {}
+func Hello() string {
+	return "hello!"
+}
+`
+	pf := NewPatchwork().MustParseFile("f0", source)
+	pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2)
+	wrapped := pf1.Wrap(pf.Patchwork)
+
+	var before bytes.Buffer
+	if err := pf.FprintCode(&before); err != nil {
+		t.Fatal(err)
+	}
+
+	ops := []Op{
+		{Type: OpAppend, Result: wrapped.Lookup("S2")},
+		{Type: OpReplace, Result: wrapped.Lookup("S")},
+		{Type: OpAppend, Result: wrapped.Lookup("Hello")},
+		{Type: OpAppendOrReplace, Result: wrapped.Lookup("Hello")},
+	}
+	j := &journal.Journal{}
+	m := fingerprint.NewManifest()
+	plan, err := pf.Plan(ops, WithActionOptions(action.WithJournal(pf.Fset, j), action.WithManifest(m)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(j.Entries) != 0 || len(m.Entries) != 0 {
+		t.Errorf("planned changes must not be recorded, but journal=%d, manifest=%d", len(j.Entries), len(m.Entries))
+	}
+
+	var b bytes.Buffer
+	if err := plan.FprintJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("plan\n%s", b.String())
+
+	expected := []PlanAction{PlanAppend, PlanNoop, PlanConflict, PlanReplace}
+	for i, step := range plan.Steps {
+		if step.Action != expected[i] {
+			t.Errorf("%s: expected action is %q, but got %q", step.Name, expected[i], step.Action)
+		}
+	}
+	if !strings.Contains(plan.Steps[3].Diff, `+	return "hello!"`) {
+		t.Errorf("unexpected diff\n%s", plan.Steps[3].Diff)
+	}
+
+	var after bytes.Buffer
+	if err := pf.FprintCode(&after); err != nil {
+		t.Fatal(err)
+	}
+	if before.String() != after.String() {
+		t.Fatalf("must not be modified\n%s", after.String())
+	}
+
+	// apply later
+	loaded, err := ReadPlan(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := pf.ApplyPlan(loaded, pf1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Applied()) != 2 {
+		t.Errorf("expected 2 operations are applied, but got %d", len(report.Applied()))
+	}
+}
+
+// TestPlanPositions
+func TestPlanPositions(t *testing.T) {
+	source := `
+package p
+func Hello() string {
+	return "hello"
+}
+`
+	source2 := `
+package p
+
+func Hello() string {
+	return "hello!"
+}
+`
+	pf := NewPatchwork().MustParseFile("dst.go", source)
+	src := NewPatchwork().MustParseFile("override.go", source2)
+
+	// without positions (e.g. generated)
+	decl := &ast.FuncDecl{Name: ast.NewIdent("Added"), Type: &ast.FuncType{Params: &ast.FieldList{}}, Body: &ast.BlockStmt{}}
+	ob := ast.NewObj(ast.Fun, "Added")
+	ob.Decl = decl
+
+	plan, err := pf.Plan([]Op{
+		{Type: OpReplace, Result: src.Lookup("Hello")},
+		{Type: OpAppend, Result: &lookup.Result{Type: lookup.TypeToplevel, Object: ob}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "override.go:4:1"; plan.Steps[0].Source == nil || plan.Steps[0].Source.String() != expected {
+		t.Errorf("expected source is %s, but %v", expected, plan.Steps[0].Source)
+	}
+	if expected := "dst.go:3:1"; plan.Steps[0].Target == nil || plan.Steps[0].Target.String() != expected {
+		t.Errorf("expected target is %s, but %v", expected, plan.Steps[0].Target)
+	}
+
+	var b bytes.Buffer
+	if err := (&Plan{Steps: plan.Steps[1:]}).FprintJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), `"source"`) || strings.Contains(b.String(), `"target"`) {
+		t.Errorf("invalid positions must be omitted, but\n%s", b.String())
+	}
+}
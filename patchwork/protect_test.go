@@ -0,0 +1,90 @@
+package patchwork
+
+import (
+	"testing"
+
+	"github.com/podhmo/astknife/action"
+)
+
+// TestProtect
+func TestProtect(t *testing.T) {
+	source := `
+package p
+
+//astknife:keep
+type S struct {
+	Name string
+}
+
+func (s *S) String() string {
+	return s.Name
+}
+
+func Hello() string {
+	return "hello"
+}
+`
+	source2 := `
+package p
+
+type S struct {}
+
+func (s *S) String() string {
+	return "s"
+}
+
+func Hello() string {
+	return "hello!"
+}
+`
+	type C struct {
+		name      string
+		msg       string
+		protected []string
+		conflict  bool
+	}
+
+	candidates := []C{
+		{
+			msg:      "marked as keep",
+			name:     "S",
+			conflict: true,
+		},
+		{
+			msg:       "listed in protect list",
+			name:      "S.String",
+			protected: []string{"S.String"},
+			conflict:  true,
+		},
+		{
+			msg:  "not protected",
+			name: "Hello",
+		},
+	}
+
+	for _, c := range candidates {
+		c := c
+		t.Run(c.msg, func(t *testing.T) {
+			pf := NewPatchwork().MustParseFile("f0", source)
+			pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2)
+
+			ok, err := pf.AppendOrReplace(pf1.Wrap(pf.Patchwork).Lookup(c.name), action.WithProtect(c.protected...))
+			if c.conflict {
+				t.Logf("should conflict %s", err)
+				if !action.IsConflict(err) {
+					t.Fatalf("conflict is expected, but got %v", err)
+				}
+				if ok {
+					t.Fatal("must not be replaced")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Fatal("must be replaced")
+			}
+		})
+	}
+}
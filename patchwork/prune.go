@@ -0,0 +1,47 @@
+package patchwork
+
+import (
+	"go/ast"
+
+	"github.com/podhmo/astknife/action"
+	"github.com/podhmo/astknife/deps"
+	"github.com/podhmo/astknife/lookup"
+)
+
+// Unreferenced : unexported declarations in lookup (all files of the package), not reachable from exported ones, main, init, test files and so on
+func (pw *Patchwork) Unreferenced() []*lookup.Result {
+	return deps.Unreferenced(pw.Fset, pw.lookup)
+}
+
+// Prune : delete the unreferenced declarations in the file (reachability is computed with all files of the package).
+// if any declaration cannot be deleted (e.g. protected), nothing is deleted.
+// the methods whose receiver type is not found are kept, so prune the files having the methods of dead types first
+func (pf *File) Prune(options ...func(*action.Config)) ([]*lookup.Result, error) {
+	declared := map[ast.Node]bool{}
+	for _, decl := range pf.File.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			declared[decl] = true
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				declared[spec] = true
+			}
+		}
+	}
+
+	pf.Begin()
+	var deleted []*lookup.Result
+	for _, r := range deps.Unreferenced(pf.Fset, pf.lookup) {
+		node := r.Node()
+		if !declared[node] {
+			continue
+		}
+		delete(declared, node) // e.g. `var x, y int`
+		if _, err := pf.Delete(r, options...); err != nil {
+			pf.Rollback()
+			return nil, err
+		}
+		deleted = append(deleted, r)
+	}
+	return deleted, pf.Commit()
+}
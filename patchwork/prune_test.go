@@ -0,0 +1,163 @@
+package patchwork
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action"
+)
+
+// TestPrune
+func TestPrune(t *testing.T) {
+	source := `
+package p
+
+import "fmt"
+
+type item struct {
+	name string
+}
+
+func (i item) String() string {
+	return fmt.Sprintf("item(%s)", i.name)
+}
+
+type dead struct{}
+
+func (d *dead) run() {
+	deadHelper()
+}
+
+func deadHelper() {}
+
+const sep = ", "
+
+var (
+	used, unused int
+)
+
+var deadVar = 1
+
+var registered = register()
+
+func register() int { return 1 }
+
+func newItem(name string) item {
+	return item{name: name}
+}
+
+type impl struct{}
+
+func (x impl) do() {}
+
+var _ interface{ do() } = impl{}
+
+func init() {
+	used = 1
+}
+
+func helper(items []item) string {
+	return items[0].String() + sep
+}
+
+// Format :
+func Format(names ...string) string {
+	items := make([]item, len(names))
+	return helper(items)
+}
+`
+	source2 := `
+package p
+
+func main() {
+	fromOther()
+}
+
+func fromOther() {}
+
+func (d dead) other() {}
+`
+	testSource := `
+package p
+
+func checkItem() string {
+	return newItem("x").String()
+}
+`
+
+	expected := []string{"dead", "dead.run", "deadHelper", "deadVar", "dead.other"}
+
+	t.Run("unreferenced", func(t *testing.T) {
+		pw := NewPatchwork()
+		pw.MustParseFile("f0", source)
+		pw.MustParseFile("f1", source2)
+		pw.MustParseFile("f_test.go", testSource)
+
+		var names []string
+		for _, r := range pw.Unreferenced() {
+			names = append(names, r.FullName())
+		}
+		if strings.Join(names, ",") != strings.Join(expected, ",") {
+			t.Errorf("expected %v, but %v", expected, names)
+		}
+	})
+
+	t.Run("pruned", func(t *testing.T) {
+		pw := NewPatchwork()
+		pf := pw.MustParseFile("f0", source)
+		pf2 := pw.MustParseFile("f1", source2)
+		pf3 := pw.MustParseFile("f_test.go", testSource)
+
+		// the methods of dead types in other files are pruned first
+		if _, err := pf2.Prune(); err != nil {
+			t.Fatal(err)
+		}
+		if deleted, err := pf3.Prune(); err != nil || len(deleted) != 0 {
+			t.Fatalf("nothing must be deleted in test files, but %d (err=%v)", len(deleted), err)
+		}
+		deleted, err := pf.Prune()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deleted) != 4 {
+			t.Errorf("expected 4 declarations are deleted, but %d", len(deleted))
+		}
+
+		code, err := printerString(pf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"dead", "deadHelper", "deadVar"} {
+			if strings.Contains(code, name) {
+				t.Errorf("%s must be deleted, but\n%s", name, code)
+			}
+		}
+		for _, expected := range []string{"func (i item) String() string", "used, unused int", "func (x impl) do()", "const sep", "var registered = register()", "func register() int", "func newItem(name string) item"} {
+			if !strings.Contains(code, expected) {
+				t.Errorf("expected %q is contained, but\n%s", expected, code)
+			}
+		}
+		if code, _ := printerString(pf2); strings.Contains(code, "dead") {
+			t.Errorf("dead.other must be deleted, but\n%s", code)
+		}
+		if len(pw.Unreferenced()) != 0 {
+			t.Errorf("nothing must be unreferenced, but %d", len(pw.Unreferenced()))
+		}
+	})
+
+	t.Run("protected, rolled back", func(t *testing.T) {
+		pw := NewPatchwork()
+		pf := pw.MustParseFile("f0", source)
+		pw.MustParseFile("f1", source2)
+
+		_, err := pf.Prune(action.WithProtect("deadHelper"))
+		var cerr *action.ConflictError
+		if !errors.As(err, &cerr) {
+			t.Fatalf("expected ConflictError, but %+v", err)
+		}
+		if pf.Lookup("deadVar") == nil {
+			t.Error("nothing must be deleted")
+		}
+	})
+}
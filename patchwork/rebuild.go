@@ -0,0 +1,28 @@
+package patchwork
+
+import (
+	"bytes"
+	"go/parser"
+
+	"github.com/pkg/errors"
+)
+
+// Rebuild : re-resolve the file (File.Scope, Object.Decl, Unresolved), by printing and parsing it again.
+// the *ast.File is updated in place, so the references to it (e.g. lookup) are kept.
+func (pf *File) Rebuild() error {
+	filename := ""
+	if tf := pf.Fset.File(pf.File.Pos()); tf != nil {
+		filename = tf.Name()
+	}
+
+	var b bytes.Buffer
+	if err := pf.FprintCode(&b); err != nil {
+		return errors.Wrap(err, "rebuild")
+	}
+	file, err := parser.ParseFile(pf.Fset, filename, b.Bytes(), parser.ParseComments)
+	if err != nil {
+		return errors.Wrap(err, "rebuild")
+	}
+	*pf.File = *file
+	return nil
+}
@@ -0,0 +1,89 @@
+package patchwork
+
+import (
+	"go/ast"
+	"testing"
+)
+
+// TestScopeConsistency
+func TestScopeConsistency(t *testing.T) {
+	source := `
+package p
+type S struct {}
+func Hello() string {
+	return "hello"
+}
+func Bye() string {
+	return "bye"
+}
+`
+	source2 := `
+package p
+type S struct { Name string }
+func Hello() string {
+	return "hello!"
+}
+func Added() *S {
+	return &S{}
+}
+`
+	pf := NewPatchwork().MustParseFile("f0", source)
+	pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2).Wrap(pf.Patchwork)
+
+	report, err := pf.ApplyAll([]Op{
+		{Type: OpReplace, Result: pf1.Lookup("S")},
+		{Type: OpReplace, Result: pf1.Lookup("Hello")},
+		{Type: OpDelete, Result: pf.Lookup("Bye")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Applied()) != 3 {
+		t.Fatalf("expected 3 operations are applied, but got %d", len(report.Applied()))
+	}
+
+	t.Run("scope is maintained by actions", func(t *testing.T) {
+		for _, name := range []string{"S", "Hello"} {
+			ob := pf.File.Scope.Lookup(name)
+			if ob == nil {
+				t.Fatalf("%s must be found", name)
+			}
+			if ob.Decl != pf1.Lookup(name).Node() {
+				t.Errorf("%s: Object.Decl must point to the replacement", name)
+			}
+		}
+		if pf.File.Scope.Lookup("Bye") != nil {
+			t.Error("deleted object must not be found")
+		}
+	})
+
+	t.Run("rebuild", func(t *testing.T) {
+		if _, err := pf.Append(pf1.Lookup("Added")); err != nil {
+			t.Fatal(err)
+		}
+		if err := pf.Rebuild(); err != nil {
+			t.Fatal(err)
+		}
+
+		// S in Added() is resolved to S in f0, after rebuilding
+		ob := pf.File.Scope.Lookup("S")
+		found := false
+		ast.Inspect(pf.Lookup("Added").Node(), func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok && ident.Name == "S" {
+				found = true
+				if ident.Obj != ob {
+					t.Errorf("S must be resolved to the object of f0")
+				}
+			}
+			return true
+		})
+		if !found {
+			t.Fatal("S must be referred")
+		}
+		for _, ident := range pf.File.Unresolved {
+			if ident.Name == "S" || ident.Name == "Hello" {
+				t.Errorf("%s must be resolved", ident.Name)
+			}
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package patchwork
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/podhmo/astknife/action"
+)
+
+// TestNormalizeReceiver
+func TestNormalizeReceiver(t *testing.T) {
+	source := `
+package p
+
+type S struct {
+	Name string
+}
+
+func (s *S) Hello() string {
+	return "hello " + s.Name
+}
+
+func (s *S) Bye() string {
+	return "bye " + s.Name
+}
+`
+	type C struct {
+		msg      string
+		source2  string
+		name     string
+		op       OpType
+		options  []func(*action.Config)
+		contains string
+	}
+
+	candidates := []C{
+		{
+			msg:      "append, not normalized",
+			source2:  "package p\nfunc (x S) String() string {\n\treturn x.Name\n}\n",
+			name:     "S.String",
+			op:       OpAppend,
+			contains: "func (x S) String() string {\n\treturn x.Name\n}",
+		},
+		{
+			msg:      "append, name",
+			source2:  "package p\nfunc (x S) String() string {\n\treturn x.Name\n}\n",
+			name:     "S.String",
+			op:       OpAppend,
+			options:  []func(*action.Config){action.WithNormalizeReceiver()},
+			contains: "func (s S) String() string {\n\treturn s.Name\n}",
+		},
+		{
+			msg:      "append, name and pointer",
+			source2:  "package p\nfunc (x S) String() string {\n\treturn x.Name\n}\n",
+			name:     "S.String",
+			op:       OpAppend,
+			options:  []func(*action.Config){action.WithNormalizeReceiver(), action.WithNormalizeReceiverPointer()},
+			contains: "func (s *S) String() string {\n\treturn s.Name\n}",
+		},
+		{
+			msg:      "replace, name",
+			source2:  "package p\nfunc (x *S) Hello() string {\n\treturn \"hi \" + x.Name\n}\n",
+			name:     "S.Hello",
+			op:       OpReplace,
+			options:  []func(*action.Config){action.WithNormalizeReceiver()},
+			contains: "func (s *S) Hello() string {\n\treturn \"hi \" + s.Name\n}",
+		},
+		{
+			msg:      "upsert, unnamed",
+			source2:  "package p\nfunc (S) Bye() string {\n\treturn \"bye\"\n}\n",
+			name:     "S.Bye",
+			op:       OpAppendOrReplace,
+			options:  []func(*action.Config){action.WithNormalizeReceiver(), action.WithNormalizeReceiverPointer()},
+			contains: "func (s *S) Bye() string {\n\treturn \"bye\"\n}",
+		},
+		{
+			msg:      "append, name is used in body, kept",
+			source2:  "package p\nfunc (x *S) String() string {\n\ts := x.Name\n\treturn s\n}\n",
+			name:     "S.String",
+			op:       OpAppend,
+			options:  []func(*action.Config){action.WithNormalizeReceiver()},
+			contains: "func (x *S) String() string {\n\ts := x.Name\n\treturn s\n}",
+		},
+	}
+
+	for _, c := range candidates {
+		c := c
+		t.Run(c.msg, func(t *testing.T) {
+			pf := NewPatchwork().MustParseFile("f0", source)
+			pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", c.source2).Wrap(pf.Patchwork)
+			original, err := printerString(pf1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := pf.ApplyAll([]Op{{Type: c.op, Result: pf1.Lookup(c.name)}}, WithActionOptions(c.options...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Applied()) != 1 {
+				t.Fatalf("must be applied, but %s", report.Ops[0].Message)
+			}
+
+			code, err := printerString(pf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(code, c.contains) {
+				t.Errorf("expected %q is contained, but\n%s", c.contains, code)
+			}
+			if err := pf.Verify(nil); err != nil {
+				t.Errorf("normalized code must be type-checked, but %s", err)
+			}
+
+			// replacement itself is not modified
+			if after, _ := printerString(pf1); after != original {
+				t.Errorf("replacement must not be modified, but\n%s", after)
+			}
+		})
+	}
+}
+
+// TestNormalizeReceiverOtherFiles
+func TestNormalizeReceiverOtherFiles(t *testing.T) {
+	pw := NewPatchwork()
+	pw.MustParseFile("f0", "package p\ntype S struct {\n\tName string\n}\nfunc (s *S) Hello() string {\n\treturn s.Name\n}\n")
+	pf := pw.MustParseFile("f1", "package p\nfunc (x S) Bye() string {\n\treturn x.Name\n}\n")
+	pw.MustParseFile("f2", "package p\nfunc (s *S) Hi() string {\n\treturn s.Name\n}\n")
+	replacement := NewPatchwork(WithFileSet(pw.Fset)).MustParseFile("r", "package p\nfunc (x S) String() string {\n\treturn x.Name\n}\n").Wrap(pw)
+
+	options := []func(*action.Config){action.WithNormalizeReceiver(), action.WithNormalizeReceiverPointer()}
+	if ok, err := pf.Append(replacement.Lookup("S.String"), options...); err != nil || !ok {
+		t.Fatalf("must be appended, but ok=%v, err=%v", ok, err)
+	}
+
+	code, err := printerString(pf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "func (s *S) String() string {\n\treturn s.Name\n}"
+	if !strings.Contains(code, expected) {
+		t.Errorf("expected %q is contained, but\n%s", expected, code)
+	}
+}
+
+func printerString(pf *File) (string, error) {
+	var b bytes.Buffer
+	err := pf.FprintCode(&b)
+	return b.String(), err
+}
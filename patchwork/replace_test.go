@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/action"
 )
 
 // TestReplace
@@ -104,3 +107,78 @@ func Hello() string {
 		})
 	}
 }
+
+// TestReplaceUnchanged
+func TestReplaceUnchanged(t *testing.T) {
+	source := `
+package p
+// S : struct
+type S struct {
+	Name string
+}
+func (s *S) String() string {
+	return s.Name
+}
+`
+	type C struct {
+		source2   string
+		name      string
+		msg       string
+		unchanged bool
+	}
+
+	candidates := []C{
+		{
+			msg:  "same struct, but formatted differently",
+			name: "S",
+			source2: `
+package p
+// S : struct
+type S struct { Name   string }
+`,
+			unchanged: true,
+		},
+		{
+			msg:  "same method",
+			name: "S.String",
+			source2: `
+package p
+func (s *S) String() string { return s.Name }
+`,
+			unchanged: true,
+		},
+		{
+			msg:  "changed method",
+			name: "S.String",
+			source2: `
+package p
+func (s *S) String() string { return "s" }
+`,
+		},
+	}
+
+	for _, c := range candidates {
+		c := c
+		t.Run(c.msg, func(t *testing.T) {
+			pf := NewPatchwork().MustParseFile("f0", source)
+			pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", c.source2)
+
+			ok, err := pf.AppendOrReplace(pf1.Wrap(pf.Patchwork).Lookup(c.name))
+			if c.unchanged {
+				if ok || errors.Cause(err) != action.ErrUnchanged {
+					t.Fatalf("unchanged is expected, but got (%v, %v)", ok, err)
+				}
+				if !action.IsNoEffect(err) {
+					t.Fatal("unchanged must be no effect")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Fatal("must be replaced")
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package patchwork
+
+import (
+	"go/ast"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotInTransaction :
+var ErrNotInTransaction = errors.New("not in transaction")
+
+// Snapshot : AST state of File.
+// actions replace nodes rather than modifying them, so copying the toplevel structure is enough.
+type Snapshot struct {
+	file   ast.File
+	scope  *ast.Scope
+	specs  map[*ast.GenDecl][]ast.Spec
+	object map[string]*ast.Object
+}
+
+// Snapshot :
+func (pf *File) Snapshot() *Snapshot {
+	f := pf.File
+	s := &Snapshot{
+		file:   *f,
+		scope:  f.Scope,
+		specs:  map[*ast.GenDecl][]ast.Spec{},
+		object: map[string]*ast.Object{},
+	}
+	s.file.Decls = append([]ast.Decl(nil), f.Decls...)
+	s.file.Imports = append([]*ast.ImportSpec(nil), f.Imports...)
+	s.file.Unresolved = append([]*ast.Ident(nil), f.Unresolved...)
+	s.file.Comments = append([]*ast.CommentGroup(nil), f.Comments...)
+	for _, decl := range f.Decls {
+		if decl, ok := decl.(*ast.GenDecl); ok {
+			s.specs[decl] = append([]ast.Spec(nil), decl.Specs...)
+		}
+	}
+	if f.Scope != nil {
+		for name, ob := range f.Scope.Objects {
+			s.object[name] = ob
+		}
+	}
+	return s
+}
+
+// Restore :
+func (pf *File) Restore(s *Snapshot) {
+	f := pf.File
+	*f = s.file
+	f.Decls = append([]ast.Decl(nil), s.file.Decls...)
+	for decl, specs := range s.specs {
+		decl.Specs = append([]ast.Spec(nil), specs...)
+	}
+	f.Scope = s.scope
+	if f.Scope != nil {
+		f.Scope.Objects = map[string]*ast.Object{}
+		for name, ob := range s.object {
+			f.Scope.Objects[name] = ob
+		}
+	}
+}
+
+// Begin : begin transaction (nested transaction is also supported)
+func (pf *File) Begin() {
+	pf.snapshots = append(pf.snapshots, pf.Snapshot())
+}
+
+// Commit :
+func (pf *File) Commit() error {
+	if len(pf.snapshots) == 0 {
+		return ErrNotInTransaction
+	}
+	pf.snapshots = pf.snapshots[:len(pf.snapshots)-1]
+	return nil
+}
+
+// Rollback : restore the state at Begin()
+func (pf *File) Rollback() error {
+	if len(pf.snapshots) == 0 {
+		return ErrNotInTransaction
+	}
+	s := pf.snapshots[len(pf.snapshots)-1]
+	pf.snapshots = pf.snapshots[:len(pf.snapshots)-1]
+	pf.Restore(s)
+	return nil
+}
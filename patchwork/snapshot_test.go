@@ -0,0 +1,110 @@
+package patchwork
+
+import (
+	"bytes"
+	"go/ast"
+	"testing"
+)
+
+// TestTransaction
+func TestTransaction(t *testing.T) {
+	source := `
+package p
+type S struct {}
+func Hello() string {
+	return "hello"
+}
+`
+	source2 := `
+package p
+type S struct { Name string }
+type S2 struct {}
+func Hello() string {
+	return "hello!"
+}
+`
+	print := func(pf *File) string {
+		var b bytes.Buffer
+		if err := pf.FprintCode(&b); err != nil {
+			t.Fatal(err)
+		}
+		return b.String()
+	}
+
+	t.Run("rollback", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", source)
+		pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2).Wrap(pf.Patchwork)
+		before := print(pf)
+
+		pf.Begin()
+		for _, name := range []string{"S", "S2", "Hello"} {
+			if _, err := pf.AppendOrReplace(pf1.Lookup(name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if print(pf) == before {
+			t.Fatal("must be modified")
+		}
+		if err := pf.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+
+		if after := print(pf); before != after {
+			t.Errorf("must be restored\nbefore:\n%s\nafter:\n%s", before, after)
+		}
+		if pf.Lookup("S2") != nil {
+			t.Error("scope must be restored")
+		}
+		if err := pf.Commit(); err != ErrNotInTransaction {
+			t.Errorf("ErrNotInTransaction is expected, but got %v", err)
+		}
+	})
+
+	t.Run("atomic", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", source)
+		pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2).Wrap(pf.Patchwork)
+		before := print(pf)
+
+		ops := []Op{
+			{Type: OpAppend, Result: pf1.Lookup("S2")},
+			{Type: OpAppend, Result: pf1.Lookup("Hello")}, // already existed
+		}
+		report, err := pf.ApplyAll(ops, WithAtomic())
+		if err == nil {
+			t.Fatal("error is expected, but no error")
+		}
+		if !report.RolledBack {
+			t.Error("must be rolled back")
+		}
+		if after := print(pf); before != after {
+			t.Errorf("must be restored\nbefore:\n%s\nafter:\n%s", before, after)
+		}
+	})
+	t.Run("atomic, rebuild failed", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", source)
+		pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", source2).Wrap(pf.Patchwork)
+		before := print(pf)
+
+		// the replacement cannot be parsed again
+		r := pf1.Lookup("Hello")
+		r.Object.Decl.(*ast.FuncDecl).Body.List[0].(*ast.ReturnStmt).Results[0] = ast.NewIdent("1x")
+
+		ops := []Op{
+			{Type: OpAppend, Result: pf1.Lookup("S2")},
+			{Type: OpReplace, Result: r},
+		}
+		report, err := pf.ApplyAll(ops, WithAtomic(), WithRebuild())
+		if err == nil {
+			t.Fatal("error is expected, but no error")
+		}
+		if !report.RolledBack {
+			t.Error("must be rolled back")
+		}
+		if after := print(pf); before != after {
+			t.Errorf("must be restored\nbefore:\n%s\nafter:\n%s", before, after)
+		}
+		if err := pf.Commit(); err != ErrNotInTransaction {
+			t.Errorf("the transaction must be finished, but got %v", err)
+		}
+	})
+}
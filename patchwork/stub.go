@@ -0,0 +1,51 @@
+package patchwork
+
+import (
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/lookup"
+	"github.com/podhmo/astknife/stub"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// Stub : append stub methods of typename, which are missing to satisfy the interface (e.g. "Reader", "io.Reader")
+func (pf *File) Stub(iface string, typename string, options ...func(*stub.Config)) ([]*lookup.Result, error) {
+	r := pf.lookup.Lookup(typename)
+	if r == nil || r.Type != lookup.TypeToplevel {
+		return nil, errors.Errorf("type %s is not found", typename)
+	}
+	stubs, err := stub.Generate(pf.Fset, pf.lookup, pf.File, iface, typename, options...)
+	if err != nil {
+		return nil, err
+	}
+	return pf.appendStubs(r, stubs)
+}
+
+// Delegate : append forwarding methods of typename, for every method of the field (methods already defined are skipped)
+func (pf *File) Delegate(typename string, field string, options ...func(*stub.Config)) ([]*lookup.Result, error) {
+	r := pf.lookup.Lookup(typename)
+	if r == nil || r.Type != lookup.TypeToplevel {
+		return nil, errors.Errorf("type %s is not found", typename)
+	}
+	stubs, err := stub.Delegate(pf.Fset, pf.lookup, typename, field, options...)
+	if err != nil {
+		return nil, err
+	}
+	return pf.appendStubs(r, stubs)
+}
+
+func (pf *File) appendStubs(r *lookup.Result, stubs *stub.Stubs) ([]*lookup.Result, error) {
+	pf.Begin()
+	var appended []*lookup.Result
+	for _, decl := range stubs.Decls {
+		mr := &lookup.Result{Type: lookup.TypeMethod, Object: r.Object, FuncDecl: decl}
+		if _, err := pf.Append(mr); err != nil {
+			pf.Rollback()
+			return nil, err
+		}
+		appended = append(appended, mr)
+	}
+	for _, path := range stubs.Imports {
+		astutil.AddImport(pf.Fset, pf.File, path)
+	}
+	return appended, pf.Commit()
+}
@@ -0,0 +1,236 @@
+package patchwork
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/podhmo/astknife/stub"
+)
+
+// TestStub
+func TestStub(t *testing.T) {
+	source := `
+package p
+
+import "io"
+
+type Named interface {
+	Name() string
+}
+
+type Person interface {
+	Named
+	io.Closer
+	Age() (age int, ok bool)
+	Parent() *S
+}
+
+type S struct {}
+
+func (s *S) Name() string {
+	return "s"
+}
+
+type P struct {}
+`
+	type C struct {
+		msg      string
+		iface    string
+		typename string // default: "S"
+		options  []func(*stub.Config)
+		appended []string
+		contains []string
+	}
+
+	candidates := []C{
+		{
+			msg:      "local interface, with embedded",
+			iface:    "Person",
+			appended: []string{"Close", "Age", "Parent"},
+			contains: []string{
+				"func (s *S) Close() error {\n\tpanic(\"not implemented\")\n}",
+				"func (s *S) Age() (age int, ok bool) {",
+				"func (s *S) Parent() *S {",
+			},
+		},
+		{
+			msg:      "local interface, zero value",
+			iface:    "Person",
+			options:  []func(*stub.Config){stub.WithZeroValue()},
+			appended: []string{"Close", "Age", "Parent"},
+			contains: []string{
+				"func (s *S) Close() error {\n\treturn nil\n}",
+				"func (s *S) Age() (age int, ok bool) {\n\treturn 0, false\n}",
+			},
+		},
+		{
+			msg:      "imported interface",
+			iface:    "io.ReadWriter",
+			appended: []string{"Read", "Write"},
+			contains: []string{
+				"func (s *S) Read(p []byte) (n int, err error) {",
+				"func (s *S) Write(p []byte) (n int, err error) {",
+			},
+		},
+		{
+			msg:      "imported interface, parameter named as receiver",
+			iface:    "io.Writer",
+			typename: "P",
+			appended: []string{"Write"},
+			contains: []string{"func (p *P) Write(p0 []byte) (n int, err error) {"},
+		},
+		{
+			msg:      "imported interface, not imported yet",
+			iface:    "fmt.Stringer",
+			appended: []string{"String"},
+			contains: []string{"func (s *S) String() string {"},
+		},
+	}
+
+	for _, c := range candidates {
+		c := c
+		t.Run(c.msg, func(t *testing.T) {
+			if c.typename == "" {
+				c.typename = "S"
+			}
+			pf := NewPatchwork().MustParseFile("f0", source)
+			appended, err := pf.Stub(c.iface, c.typename, c.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, r := range appended {
+				names = append(names, r.Name())
+			}
+			if strings.Join(names, ",") != strings.Join(c.appended, ",") {
+				t.Fatalf("expected appended methods are %v, but %v", c.appended, names)
+			}
+
+			var b bytes.Buffer
+			if err := pf.FprintCode(&b); err != nil {
+				t.Fatal(err)
+			}
+			for _, expected := range c.contains {
+				if !strings.Contains(b.String(), expected) {
+					t.Errorf("expected %q is contained, but\n%s", expected, b.String())
+				}
+			}
+			if err := pf.Verify(nil); err != nil {
+				t.Errorf("stubbed code must be type-checked, but %s", err)
+			}
+
+			// idempotent, all methods are already existed
+			appended, err = pf.Stub(c.iface, c.typename, c.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(appended) != 0 {
+				t.Errorf("expected nothing is appended, but %d", len(appended))
+			}
+		})
+	}
+}
+
+// TestDelegate
+func TestDelegate(t *testing.T) {
+	source := `
+package p
+
+import "strings"
+
+type T struct {}
+
+func (t *T) Hello(name string) string {
+	return "hello " + name
+}
+
+func (t T) Join(sep string, xs ...string) string {
+	return strings.Join(xs, sep)
+}
+
+func (t *T) Reset() {
+}
+
+func (t *T) unexported() {
+}
+
+type S struct {
+	t T
+	b *strings.Builder
+}
+
+func (s *S) Reset() {
+	s.t.Reset()
+	s.b.Reset()
+}
+`
+	type C struct {
+		msg      string
+		field    string
+		appended []string
+		contains []string
+	}
+
+	candidates := []C{
+		{
+			msg:      "local type",
+			field:    "t",
+			appended: []string{"Hello", "Join", "unexported"},
+			contains: []string{
+				"func (s *S) Hello(name string) string {\n\treturn s.t.Hello(name)\n}",
+				"func (s *S) Join(sep string, xs ...string) string {\n\treturn s.t.Join(sep, xs...)\n}",
+				"func (s *S) unexported() {\n\ts.t.unexported()\n}",
+			},
+		},
+		{
+			msg:      "imported type",
+			field:    "b",
+			appended: []string{"Cap", "Grow", "Len", "String", "Write", "WriteByte", "WriteRune", "WriteString"},
+			contains: []string{
+				"func (s *S) Write(p []byte) (int, error) {\n\treturn s.b.Write(p)\n}",
+				"func (s *S) Grow(n int) {\n\ts.b.Grow(n)\n}",
+			},
+		},
+	}
+
+	for _, c := range candidates {
+		c := c
+		t.Run(c.msg, func(t *testing.T) {
+			pf := NewPatchwork().MustParseFile("f0", source)
+			appended, err := pf.Delegate("S", c.field)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, r := range appended {
+				names = append(names, r.Name())
+			}
+			if strings.Join(names, ",") != strings.Join(c.appended, ",") {
+				t.Fatalf("expected appended methods are %v, but %v", c.appended, names)
+			}
+
+			var b bytes.Buffer
+			if err := pf.FprintCode(&b); err != nil {
+				t.Fatal(err)
+			}
+			for _, expected := range c.contains {
+				if !strings.Contains(b.String(), expected) {
+					t.Errorf("expected %q is contained, but\n%s", expected, b.String())
+				}
+			}
+			if err := pf.Verify(nil); err != nil {
+				t.Errorf("delegated code must be type-checked, but %s", err)
+			}
+		})
+	}
+
+	t.Run("field not found", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", source)
+		if _, err := pf.Delegate("S", "missing"); err == nil {
+			t.Error("expected error, but nil")
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package patchwork
+
+import (
+	"bytes"
+	"go/scanner"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// TestParseTemplate
+func TestParseTemplate(t *testing.T) {
+	source := `
+package p
+
+type S struct {
+	Name string
+}
+`
+	template := "package p\n" +
+		"\n" +
+		"// New__Type__ : constructor of __Type__\n" +
+		"func New__Type__(__Field__ string) *__Type__ {\n" +
+		"\tif __Field__ == \"\" {\n" +
+		"\t\tpanic(\"__Field__ is required, \\\"__Type__\\\"\")\n" +
+		"\t}\n" +
+		"\treturn &__Type__{__Field__: __Field__}\n" +
+		"}\n"
+
+	t.Run("instantiated", func(t *testing.T) {
+		pf := NewPatchwork().MustParseFile("f0", source)
+		tmpl, err := NewPatchwork(WithFileSet(pf.Fset)).ParseTemplate("tmpl", template, map[string]string{"Type": "S", "Field": "Name"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pf.Append(tmpl.Wrap(pf.Patchwork).Lookup("NewS")); err != nil {
+			t.Fatal(err)
+		}
+
+		var b bytes.Buffer
+		if err := pf.FprintCode(&b); err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range []string{
+			"func NewS(Name string) *S {",
+			`panic("Name is required, \"S\"")`,
+			"return &S{Name: Name}",
+		} {
+			if !strings.Contains(b.String(), expected) {
+				t.Errorf("expected %q is contained, but\n%s", expected, b.String())
+			}
+		}
+		if err := pf.Verify(nil); err != nil {
+			t.Errorf("instantiated code must be type-checked, but %s", err)
+		}
+	})
+
+	t.Run("unbound placeholder", func(t *testing.T) {
+		_, err := NewPatchwork().ParseTemplate("tmpl", template, map[string]string{"Type": "S"})
+		var errs scanner.ErrorList
+		if !errors.As(err, &errs) {
+			t.Fatalf("expected scanner.ErrorList, but %+v", err)
+		}
+		if errs[0].Pos.String() != "tmpl:4:18" || !strings.Contains(errs[0].Msg, "__Field__") {
+			t.Errorf("unexpected error %s", errs[0])
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package patchwork
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"strings"
+
+	"github.com/podhmo/astknife/lookup"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// TypeError : type error, mapped back to the operation which caused it
+type TypeError struct {
+	Err types.Error
+	Op  *OpReport // nil, if not caused by any operation
+}
+
+func (e *TypeError) Error() string {
+	if e.Op == nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s (caused by %s %s)", e.Err.Error(), e.Op.Type, e.Op.Name)
+}
+
+// VerifyError :
+type VerifyError struct {
+	Errors []*TypeError
+}
+
+func (e *VerifyError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("verification failed:\n\t%s", strings.Join(messages, "\n\t"))
+}
+
+// Verify : type-check the patched file (with siblings, the other files of the same package).
+// type errors are mapped back to the applied operations in report (report can be nil)
+func (pf *File) Verify(report *Report, siblings ...*File) error {
+	files := []*ast.File{pf.File}
+	for _, sibling := range siblings {
+		files = append(files, sibling.File)
+	}
+
+	var errs []*TypeError
+	conf := &types.Config{
+		Importer: importer.ForCompiler(pf.Fset, "source", nil),
+		Error: func(err error) {
+			if terr, ok := err.(types.Error); ok {
+				errs = append(errs, &TypeError{Err: terr, Op: pf.causedBy(report, terr.Pos)})
+			}
+		},
+	}
+	conf.Check(pf.File.Name.Name, pf.Fset, files, nil)
+	if len(errs) > 0 {
+		return &VerifyError{Errors: errs}
+	}
+	return nil
+}
+
+// causedBy : find the operation, whose declaration contains pos
+func (pf *File) causedBy(report *Report, pos token.Pos) *OpReport {
+	if report == nil {
+		return nil
+	}
+
+	applied := report.Applied()
+	// transplanted declaration keeps the positions of its source
+	for _, op := range applied {
+		if op.node != nil && op.node.Pos() <= pos && pos < op.node.End() {
+			return op
+		}
+	}
+	// e.g. signature mismatch, the error is in the caller, which refers the applied declaration
+	path, _ := astutil.PathEnclosingInterval(pf.File, pos, pos)
+	for _, node := range path {
+		var found *OpReport
+		ast.Inspect(node, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok && found == nil {
+				for _, op := range applied {
+					if op.Name == ident.Name || strings.HasSuffix(op.Name, "."+ident.Name) {
+						found = op
+					}
+				}
+			}
+			return found == nil
+		})
+		if found != nil {
+			return found
+		}
+		if _, ok := node.(ast.Stmt); ok {
+			break
+		}
+	}
+	// e.g. the error is in the declaration of destination, but caused by the operation
+	for _, r := range lookup.New(pf.File).All() {
+		node := r.Node()
+		if node == nil || !(node.Pos() <= pos && pos < node.End()) {
+			continue
+		}
+		for _, op := range applied {
+			if op.Name == r.FullName() {
+				return op
+			}
+		}
+	}
+	return nil
+}
+
+// WriteFile :
+func (pf *File) WriteFile(filename string) error {
+	// print before opening, not to truncate the file on failure
+	var b bytes.Buffer
+	if err := pf.FprintCode(&b); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, b.Bytes(), 0644)
+}
+
+// VerifiedWriteFile : write the file, only if verification is passed
+func (pf *File) VerifiedWriteFile(filename string, report *Report, siblings ...*File) error {
+	if err := pf.Verify(report, siblings...); err != nil {
+		return err
+	}
+	return pf.WriteFile(filename)
+}
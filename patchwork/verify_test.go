@@ -0,0 +1,101 @@
+package patchwork
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestVerify
+func TestVerify(t *testing.T) {
+	source := `
+package p
+type S struct {}
+func Hello() string {
+	return "hello"
+}
+func Use() string {
+	return Hello()
+}
+`
+	type C struct {
+		source2 string
+		name    string
+		msg     string
+		hasErr  bool
+	}
+
+	candidates := []C{
+		{
+			msg:  "ok",
+			name: "Hello",
+			source2: `
+package p
+func Hello() string {
+	return "hello!"
+}
+`,
+		},
+		{
+			msg:  "missing import",
+			name: "Hello",
+			source2: `
+package p
+func Hello() string {
+	return strings.ToUpper("hello")
+}
+`,
+			hasErr: true,
+		},
+		{
+			msg:  "signature mismatch with callers",
+			name: "Hello",
+			source2: `
+package p
+func Hello() int {
+	return 1
+}
+`,
+			hasErr: true,
+		},
+	}
+
+	for _, c := range candidates {
+		c := c
+		t.Run(c.msg, func(t *testing.T) {
+			pf := NewPatchwork().MustParseFile("f0", source)
+			pf1 := NewPatchwork(WithFileSet(pf.Fset)).MustParseFile("f1", c.source2).Wrap(pf.Patchwork)
+
+			report, err := pf.ApplyAll([]Op{{Type: OpReplace, Result: pf1.Lookup(c.name)}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			filename := filepath.Join(t.TempDir(), "f0.go")
+			err = pf.VerifiedWriteFile(filename, report)
+			if !c.hasErr {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := os.Stat(filename); err != nil {
+					t.Fatal("must be written")
+				}
+				return
+			}
+
+			t.Logf("should error %s", err)
+			verr, ok := err.(*VerifyError)
+			if !ok {
+				t.Fatalf("VerifyError is expected, but got %v", err)
+			}
+			for _, terr := range verr.Errors {
+				if terr.Op == nil || terr.Op.Name != c.name {
+					t.Errorf("error must be mapped to the operation, %s", terr)
+				}
+			}
+			if _, err := os.Stat(filename); !os.IsNotExist(err) {
+				t.Fatal("must not be written")
+			}
+		})
+	}
+}
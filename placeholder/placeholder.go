@@ -0,0 +1,67 @@
+package placeholder
+
+import (
+	"bytes"
+	"go/scanner"
+	"go/token"
+	"regexp"
+	"strconv"
+)
+
+// Pattern : placeholder (e.g. `__Type__`, `New__Type__`, "__Field__ is required")
+var Pattern = regexp.MustCompile(`__([A-Za-z][A-Za-z0-9]*)__`)
+
+// Expand : substitute placeholders in identifiers, strings and comments, by bindings.
+// unbound placeholders are reported as scanner.ErrorList
+func Expand(filename string, src []byte, bindings map[string]string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file := fset.AddFile(filename, -1, len(src))
+
+	var errs scanner.ErrorList
+	var s scanner.Scanner
+	s.Init(file, src, func(pos token.Position, msg string) { errs.Add(pos, msg) }, scanner.ScanComments)
+
+	var b bytes.Buffer
+	offset := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		switch tok {
+		case token.IDENT, token.STRING, token.CHAR, token.COMMENT:
+		default:
+			continue
+		}
+
+		start := file.Offset(pos)
+		b.Write(src[offset:start])
+		offset = start + len(lit)
+
+		quoted := (tok == token.STRING || tok == token.CHAR) && lit[0] != '`'
+		for _, m := range Pattern.FindAllStringSubmatchIndex(lit, -1) {
+			name := lit[m[2]:m[3]]
+			if _, ok := bindings[name]; !ok {
+				errs.Add(fset.Position(pos+token.Pos(m[0])), "unbound placeholder "+lit[m[0]:m[1]])
+			}
+		}
+		b.WriteString(Pattern.ReplaceAllStringFunc(lit, func(placeholder string) string {
+			value, ok := bindings[placeholder[2:len(placeholder)-2]]
+			if !ok {
+				return placeholder
+			}
+			if quoted {
+				quotedValue := strconv.Quote(value)
+				return quotedValue[1 : len(quotedValue)-1]
+			}
+			return value
+		}))
+	}
+	b.Write(src[offset:])
+
+	errs.Sort()
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
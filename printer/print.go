@@ -6,6 +6,7 @@ import (
 	"go/token"
 	"io"
 	"os"
+	"strings"
 )
 
 // FprintCode :
@@ -24,3 +25,12 @@ func PrintCode(fset *token.FileSet, node ast.Node) error {
 func PrintAST(fset *token.FileSet, node ast.Node) error {
 	return ast.Print(fset, node)
 }
+
+// SprintCode :
+func SprintCode(fset *token.FileSet, node ast.Node) (string, error) {
+	var b strings.Builder
+	if err := FprintCode(&b, fset, node); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,74 @@
+package resolve
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/mod/modfile"
+)
+
+// gomod : the part of go.mod, used for resolving packages
+type gomod struct {
+	Dir      string            // directory of go.mod
+	Path     string            // module path
+	Require  map[string]string // module path -> version
+	Replace  map[string]string // module path -> directory (only local replacement)
+	Replaced map[string]string // module path -> module path@version
+}
+
+// findModfile : go.mod in dir, or its parents
+func findModfile(dir string) (*gomod, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		filename := filepath.Join(dir, "go.mod")
+		if _, err := os.Stat(filename); err == nil {
+			return readModfile(filename)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+func readModfile(filename string) (*gomod, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	f, err := modfile.ParseLax(filename, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &gomod{
+		Dir:      filepath.Dir(filename),
+		Require:  map[string]string{},
+		Replace:  map[string]string{},
+		Replaced: map[string]string{},
+	}
+	if f.Module != nil {
+		m.Path = f.Module.Mod.Path
+	}
+	for _, r := range f.Require {
+		m.Require[r.Mod.Path] = r.Mod.Version
+	}
+	// e.g. `a/b => ../b`, `a/b v1.0.0 => c/d v1.1.0`
+	for _, r := range f.Replace {
+		if r.New.Version == "" {
+			target := r.New.Path
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(m.Dir, target)
+			}
+			m.Replace[r.Old.Path] = target
+			continue
+		}
+		m.Replaced[r.Old.Path] = r.New.Path + "@" + r.New.Version
+	}
+	return m, nil
+}
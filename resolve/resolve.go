@@ -0,0 +1,241 @@
+package resolve
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Config :
+type Config struct {
+	// Context : GOROOT, GOPATH and build constraints (default: build.Default)
+	Context *build.Context
+	// ModCache : module cache directory (default: $GOMODCACHE or $GOPATH/pkg/mod)
+	ModCache string
+}
+
+// WithContext :
+func WithContext(ctxt *build.Context) func(*Config) {
+	return func(c *Config) {
+		c.Context = ctxt
+	}
+}
+
+// WithModCache :
+func WithModCache(dir string) func(*Config) {
+	return func(c *Config) {
+		c.ModCache = dir
+	}
+}
+
+func newConfig(options []func(*Config)) *Config {
+	c := &Config{}
+	for _, op := range options {
+		op(c)
+	}
+	if c.Context == nil {
+		c.Context = &build.Default
+	}
+	if c.ModCache == "" {
+		c.ModCache = os.Getenv("GOMODCACHE")
+	}
+	if c.ModCache == "" {
+		if gopaths := filepath.SplitList(c.Context.GOPATH); len(gopaths) > 0 {
+			c.ModCache = filepath.Join(gopaths[0], "pkg", "mod")
+		}
+	}
+	return c
+}
+
+// PackageNotFoundError :
+type PackageNotFoundError struct {
+	Path  string
+	Tried []string // directories
+}
+
+func (e *PackageNotFoundError) Error() string {
+	return fmt.Sprintf("package %s is not found (tried: %s)", e.Path, strings.Join(e.Tried, ", "))
+}
+
+// Dir : directory of the package, resolved offline from the directory `from`.
+// the main module, local replacements, vendor, module cache (go.mod), GOPATH and GOROOT are searched in order
+func Dir(path string, from string, options ...func(*Config)) (string, error) {
+	c := newConfig(options)
+
+	var tried []string
+	try := func(dir string) bool {
+		tried = append(tried, dir)
+		info, err := os.Stat(dir)
+		return err == nil && info.IsDir()
+	}
+
+	m, err := findModfile(from)
+	if err != nil {
+		return "", err
+	}
+	if m != nil {
+		if rest, ok := within(path, m.Path); ok {
+			if dir := filepath.Join(m.Dir, rest); try(dir) {
+				return dir, nil
+			}
+		}
+		if modpath, rest := longest(path, m.Replace); modpath != "" {
+			if dir := filepath.Join(m.Replace[modpath], rest); try(dir) {
+				return dir, nil
+			}
+		}
+		if dir := filepath.Join(m.Dir, "vendor", filepath.FromSlash(path)); try(dir) {
+			return dir, nil
+		}
+		if modpath, rest := longest(path, m.Require); modpath != "" && c.ModCache != "" {
+			module := modpath + "@" + m.Require[modpath]
+			if replaced, ok := m.Replaced[modpath]; ok {
+				module = replaced
+			}
+			i := strings.LastIndex(module, "@")
+			if dir := filepath.Join(c.ModCache, escape(module[:i])+module[i:], rest); try(dir) {
+				return dir, nil
+			}
+		}
+	}
+	for _, gopath := range filepath.SplitList(c.Context.GOPATH) {
+		if dir := filepath.Join(gopath, "src", filepath.FromSlash(path)); try(dir) {
+			return dir, nil
+		}
+	}
+	if dir := filepath.Join(c.Context.GOROOT, "src", filepath.FromSlash(path)); try(dir) {
+		return dir, nil
+	}
+	return "", &PackageNotFoundError{Path: path, Tried: tried}
+}
+
+// Package : parse the files of the package, matched with build context (test files are excluded)
+func Package(fset *token.FileSet, path string, from string, options ...func(*Config)) ([]*ast.File, error) {
+	dir, err := Dir(path, from, options...)
+	if err != nil {
+		return nil, err
+	}
+	return PackageInDir(fset, dir, options...)
+}
+
+// PackageInDir : parse the files of the package in dir (the directory resolved by Dir)
+func PackageInDir(fset *token.FileSet, dir string, options ...func(*Config)) ([]*ast.File, error) {
+	c := newConfig(options)
+	pkg, err := c.Context.ImportDir(dir, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "import %s", dir)
+	}
+
+	var files []*ast.File
+	for _, name := range append(append([]string(nil), pkg.GoFiles...), pkg.CgoFiles...) {
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// SplitName : split the qualified name into import path and name in the package.
+// e.g. "github.com/foo/bar.Type.Method" -> ("github.com/foo/bar", "Type.Method"), "bar.Type" -> ("github.com/foo/bar", "Type") if imported in file.
+// the import path including dots (e.g. "gopkg.in/yaml.v3.Node") is matched with the longest one of the imports in file and the modules in go.mod (found from the directory `from`)
+func SplitName(file *ast.File, name string, from string) (path string, rest string, ok bool) {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		var candidates []string
+		if file != nil {
+			for _, spec := range file.Imports {
+				if path, err := strconv.Unquote(spec.Path.Value); err == nil {
+					candidates = append(candidates, path)
+				}
+			}
+		}
+		if m, err := findModfile(from); err == nil && m != nil {
+			candidates = append(candidates, m.Path)
+			for modpath := range m.Require {
+				candidates = append(candidates, modpath)
+			}
+			for modpath := range m.Replace {
+				candidates = append(candidates, modpath)
+			}
+		}
+		for _, candidate := range candidates {
+			if len(candidate) > len(path) && strings.HasPrefix(name, candidate+".") && !strings.Contains(name[len(candidate):], "/") {
+				path = candidate
+			}
+		}
+		if path != "" {
+			return path, name[len(path)+1:], true
+		}
+
+		j := strings.Index(name[i:], ".")
+		if j < 0 {
+			return "", "", false
+		}
+		return name[:i+j], name[i+j+1:], true
+	}
+
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) != 2 || file == nil {
+		return "", "", false
+	}
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if spec.Name != nil {
+			if spec.Name.Name == parts[0] {
+				return path, parts[1], true
+			}
+			continue
+		}
+		if path == parts[0] || strings.HasSuffix(path, "/"+parts[0]) {
+			return path, parts[1], true
+		}
+	}
+	return "", "", false
+}
+
+// within : path is in the module, or not (rest is the relative path in the module)
+func within(path string, modpath string) (string, bool) {
+	if path == modpath {
+		return "", true
+	}
+	if strings.HasPrefix(path, modpath+"/") {
+		return filepath.FromSlash(strings.TrimPrefix(path, modpath+"/")), true
+	}
+	return "", false
+}
+
+// longest : the longest module path, including path
+func longest(path string, modules map[string]string) (modpath string, rest string) {
+	for candidate := range modules {
+		if r, ok := within(path, candidate); ok && len(candidate) > len(modpath) {
+			modpath, rest = candidate, r
+		}
+	}
+	return modpath, rest
+}
+
+// escape : module path in module cache (e.g. "github.com/Foo/bar" -> "github.com/!foo/bar")
+func escape(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if 'A' <= r && r <= 'Z' {
+			b.WriteByte('!')
+			b.WriteRune(r + ('a' - 'A'))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
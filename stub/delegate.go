@@ -0,0 +1,106 @@
+package stub
+
+import (
+	"fmt"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/lookup"
+)
+
+// Delegate : generate forwarding methods of typename, for every method of the field (e.g. `func (s *S) M() { s.t.M() }`).
+// the method set of the field is computed by go/types, and methods already defined on typename are skipped
+func Delegate(fset *token.FileSet, k *lookup.Lookup, typename string, field string, options ...func(*Config)) (*Stubs, error) {
+	c := &Config{}
+	for _, op := range options {
+		op(c)
+	}
+	if c.Importer == nil {
+		c.Importer = importer.ForCompiler(fset, "source", nil)
+	}
+	if len(k.Files) == 0 {
+		return nil, errors.Errorf("type %s is not found", typename)
+	}
+
+	// type errors are ignored, the destination is often incomplete
+	conf := &types.Config{Importer: c.Importer, Error: func(error) {}}
+	pkg, _ := conf.Check(k.Files[0].Name.Name, fset, k.Files, nil)
+	ob, ok := pkg.Scope().Lookup(typename).(*types.TypeName)
+	if !ok {
+		return nil, errors.Errorf("type %s is not found", typename)
+	}
+	st, ok := ob.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, errors.Errorf("%s is not struct", typename)
+	}
+
+	var ft types.Type
+	fields := map[string]bool{}
+	for i := 0; i < st.NumFields(); i++ {
+		fields[st.Field(i).Name()] = true
+		if st.Field(i).Name() == field {
+			ft = st.Field(i).Type()
+		}
+	}
+	if ft == nil {
+		return nil, errors.Errorf("field %s.%s is not found", typename, field)
+	}
+	if _, ok := ft.(*types.Pointer); !ok && !types.IsInterface(ft) {
+		ft = types.NewPointer(ft)
+	}
+
+	imports := map[string]bool{}
+	qf := func(p *types.Package) string {
+		if p == pkg {
+			return ""
+		}
+		imports[p.Path()] = true
+		return p.Name()
+	}
+
+	existed, recv, recvType := receiver(k, typename)
+	var b strings.Builder
+	b.WriteString("package p\n")
+	mset := types.NewMethodSet(ft)
+	for i := 0; i < mset.Len(); i++ {
+		fn := mset.At(i).Obj().(*types.Func)
+		if existed[fn.Name()] || fields[fn.Name()] || (!fn.Exported() && fn.Pkg() != pkg) {
+			continue
+		}
+
+		sig := fn.Type().(*types.Signature)
+		var params, args, results []string
+		for j := 0; j < sig.Params().Len(); j++ {
+			v := sig.Params().At(j)
+			name := v.Name()
+			if name == "" || name == "_" || name == recv {
+				name = fmt.Sprintf("p%d", j)
+			}
+			typ := types.TypeString(v.Type(), qf)
+			arg := name
+			if sig.Variadic() && j == sig.Params().Len()-1 {
+				typ = "..." + types.TypeString(v.Type().(*types.Slice).Elem(), qf)
+				arg += "..."
+			}
+			params = append(params, name+" "+typ)
+			args = append(args, arg)
+		}
+		for j := 0; j < sig.Results().Len(); j++ {
+			results = append(results, types.TypeString(sig.Results().At(j).Type(), qf))
+		}
+
+		fmt.Fprintf(&b, "\nfunc (%s %s) %s(%s)", recv, recvType, fn.Name(), strings.Join(params, ", "))
+		switch len(results) {
+		case 0:
+			fmt.Fprintf(&b, " {\n\t%s.%s.%s(%s)\n}\n", recv, field, fn.Name(), strings.Join(args, ", "))
+		case 1:
+			fmt.Fprintf(&b, " %s {\n\treturn %s.%s.%s(%s)\n}\n", results[0], recv, field, fn.Name(), strings.Join(args, ", "))
+		default:
+			fmt.Fprintf(&b, " (%s) {\n\treturn %s.%s.%s(%s)\n}\n", strings.Join(results, ", "), recv, field, fn.Name(), strings.Join(args, ", "))
+		}
+	}
+	return parse(fset, b.String(), imports)
+}
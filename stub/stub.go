@@ -0,0 +1,390 @@
+package stub
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/podhmo/astknife/bypos"
+	"github.com/podhmo/astknife/lookup"
+	"github.com/podhmo/astknife/printer"
+)
+
+// Config :
+type Config struct {
+	// ZeroValue : if true, stubs return zero values, instead of panic("not implemented")
+	ZeroValue bool
+	Importer  types.Importer
+}
+
+// WithZeroValue :
+func WithZeroValue() func(*Config) {
+	return func(c *Config) {
+		c.ZeroValue = true
+	}
+}
+
+// WithImporter :
+func WithImporter(importer types.Importer) func(*Config) {
+	return func(c *Config) {
+		c.Importer = importer
+	}
+}
+
+// Stubs : generated methods
+type Stubs struct {
+	Decls   []*ast.FuncDecl
+	Imports []string // import paths, required by the generated methods
+}
+
+// Method : method of interface
+type Method struct {
+	Name      string
+	Signature string   // e.g. "(p []byte) (n int, err error)"
+	Zeros     []string // zero values of results
+}
+
+// Generate : generate stub methods of typename, which are missing to satisfy the interface.
+// iface is the name of local interface (e.g. "Reader"), or the qualified name (e.g. "io.Reader"), resolved through the imports of dst
+func Generate(fset *token.FileSet, k *lookup.Lookup, dst *ast.File, iface string, typename string, options ...func(*Config)) (*Stubs, error) {
+	c := &Config{}
+	for _, op := range options {
+		op(c)
+	}
+	if c.Importer == nil {
+		c.Importer = importer.ForCompiler(fset, "source", nil)
+	}
+
+	ob := k.Lookup(typename)
+	if ob == nil {
+		return nil, errors.Errorf("type %s is not found", typename)
+	}
+
+	m := &methods{Config: c, fset: fset, k: k, imports: map[string]bool{}, seen: map[string]bool{}}
+	if err := m.collect(dst, iface); err != nil {
+		return nil, err
+	}
+
+	existed, recv, recvType := receiver(k, typename)
+	var b strings.Builder
+	b.WriteString("package p\n")
+	for _, method := range m.methods {
+		if existed[method.Name] {
+			continue
+		}
+		fmt.Fprintf(&b, "\nfunc (%s %s) %s%s {\n", recv, recvType, method.Name, method.Signature)
+		if c.ZeroValue {
+			if len(method.Zeros) > 0 {
+				fmt.Fprintf(&b, "\treturn %s\n", strings.Join(method.Zeros, ", "))
+			}
+		} else {
+			b.WriteString("\tpanic(\"not implemented\")\n")
+		}
+		b.WriteString("}\n")
+	}
+	stubs, err := parse(fset, b.String(), m.imports)
+	if err != nil {
+		return nil, err
+	}
+	for _, decl := range stubs.Decls {
+		unshadow(decl, recv)
+	}
+	return stubs, nil
+}
+
+// unshadow : rename the parameters and results named as same as the receiver (e.g. `func (p *Printer) Write(p []byte)`), as Delegate does
+func unshadow(decl *ast.FuncDecl, recv string) {
+	var idents []*ast.Ident
+	used := map[string]bool{}
+	for _, fields := range []*ast.FieldList{decl.Type.Params, decl.Type.Results} {
+		if fields == nil {
+			continue
+		}
+		for _, field := range fields.List {
+			for _, ident := range field.Names {
+				idents = append(idents, ident)
+				used[ident.Name] = true
+			}
+		}
+	}
+	for j, ident := range idents {
+		if ident.Name != recv {
+			continue
+		}
+		name := fmt.Sprintf("p%d", j)
+		for i := j; used[name]; i++ {
+			name = fmt.Sprintf("p%d", i+1)
+		}
+		used[name] = true
+		ident.Name = name
+	}
+}
+
+// receiver : names of existing methods, and receiver name and type (e.g. "s", "*S"), following the existing methods
+func receiver(k *lookup.Lookup, typename string) (map[string]bool, string, string) {
+	existed := map[string]bool{}
+	name, pointer := "", true
+	for _, r := range k.AllMethods(typename) {
+		existed[r.Name()] = true
+		if field := r.FuncDecl.Recv.List[0]; name == "" && len(field.Names) > 0 {
+			name = field.Names[0].Name
+			_, pointer = field.Type.(*ast.StarExpr)
+		}
+	}
+	if name == "" {
+		name = strings.ToLower(typename[:1])
+	}
+	if pointer {
+		return existed, name, "*" + typename
+	}
+	return existed, name, typename
+}
+
+func parse(fset *token.FileSet, source string, imports map[string]bool) (*Stubs, error) {
+	f, err := parser.ParseFile(fset, "", source, parser.ParseComments)
+	if err != nil {
+		return nil, errors.Wrap(err, "generate stubs")
+	}
+	stubs := &Stubs{}
+	for _, decl := range f.Decls {
+		stubs.Decls = append(stubs.Decls, decl.(*ast.FuncDecl))
+	}
+	for path := range imports {
+		stubs.Imports = append(stubs.Imports, path)
+	}
+	sort.Strings(stubs.Imports)
+	return stubs, nil
+}
+
+type methods struct {
+	*Config
+	fset    *token.FileSet
+	k       *lookup.Lookup
+	methods []*Method
+	imports map[string]bool
+	seen    map[string]bool
+}
+
+func (m *methods) add(method *Method) {
+	if m.seen[method.Name] {
+		return
+	}
+	m.seen[method.Name] = true
+	m.methods = append(m.methods, method)
+}
+
+// collect : name is resolved in file (e.g. "Reader", "io.Reader")
+func (m *methods) collect(file *ast.File, name string) error {
+	if strings.Contains(name, ".") {
+		parts := strings.SplitN(name, ".", 2)
+		return m.collectFromTypes(importPath(file, parts[0]), parts[1])
+	}
+
+	r := m.k.Lookup(name)
+	if r == nil || r.Type != lookup.TypeToplevel {
+		return errors.Errorf("interface %s is not found", name)
+	}
+	spec, ok := r.Object.Decl.(*ast.TypeSpec)
+	if !ok {
+		return errors.Errorf("%s is not interface", name)
+	}
+	it, ok := spec.Type.(*ast.InterfaceType)
+	if !ok {
+		return errors.Errorf("%s is not interface", name)
+	}
+
+	files := append([]*ast.File(nil), m.k.Files...)
+	defined := bypos.FindFile(bypos.SortFiles(files), spec.Pos())
+	for _, field := range it.Methods.List {
+		switch t := field.Type.(type) {
+		case *ast.FuncType:
+			signature, err := printer.SprintCode(m.fset, t)
+			if err != nil {
+				return err
+			}
+			var zeros []string
+			if t.Results != nil {
+				for _, result := range t.Results.List {
+					zero, err := m.zeroOfExpr(result.Type)
+					if err != nil {
+						return err
+					}
+					for i := 0; i < len(result.Names) || (i == 0 && len(result.Names) == 0); i++ {
+						zeros = append(zeros, zero)
+					}
+				}
+			}
+			m.addImportsOf(defined, t)
+			for _, ident := range field.Names {
+				m.add(&Method{Name: ident.Name, Signature: strings.TrimPrefix(signature, "func"), Zeros: zeros})
+			}
+		case *ast.Ident: // embedded
+			if err := m.collect(defined, t.Name); err != nil {
+				return err
+			}
+		case *ast.SelectorExpr: // embedded, imported
+			if x, ok := t.X.(*ast.Ident); ok {
+				if err := m.collectFromTypes(importPath(defined, x.Name), t.Sel.Name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// collectFromTypes : the interface defined in the imported package
+func (m *methods) collectFromTypes(path string, name string) error {
+	pkg, err := m.Importer.Import(path)
+	if err != nil {
+		return errors.Wrapf(err, "import %s", path)
+	}
+	ob := pkg.Scope().Lookup(name)
+	if ob == nil {
+		return errors.Errorf("interface %s.%s is not found", path, name)
+	}
+	it, ok := ob.Type().Underlying().(*types.Interface)
+	if !ok {
+		return errors.Errorf("%s.%s is not interface", path, name)
+	}
+
+	qf := func(p *types.Package) string {
+		m.imports[p.Path()] = true
+		return p.Name()
+	}
+	for i := 0; i < it.NumMethods(); i++ {
+		method := it.Method(i)
+		sig := method.Type().(*types.Signature)
+		var zeros []string
+		for j := 0; j < sig.Results().Len(); j++ {
+			zeros = append(zeros, zeroOfType(sig.Results().At(j).Type(), qf))
+		}
+		m.add(&Method{
+			Name:      method.Name(),
+			Signature: strings.TrimPrefix(types.TypeString(sig, qf), "func"),
+			Zeros:     zeros,
+		})
+	}
+	return nil
+}
+
+// addImportsOf : imports referred in node (e.g. `io.Reader`)
+func (m *methods) addImportsOf(file *ast.File, node ast.Node) {
+	if file == nil {
+		return
+	}
+	ast.Inspect(node, func(node ast.Node) bool {
+		if t, ok := node.(*ast.SelectorExpr); ok {
+			if x, ok := t.X.(*ast.Ident); ok {
+				if path := importPath(file, x.Name); path != x.Name || hasImport(file, path) {
+					m.imports[path] = true
+				}
+			}
+		}
+		return true
+	})
+}
+
+func (m *methods) zeroOfExpr(expr ast.Expr) (string, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "bool":
+			return "false", nil
+		case "string":
+			return `""`, nil
+		case "error", "any":
+			return "nil", nil
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+			"float32", "float64", "complex64", "complex128", "byte", "rune":
+			return "0", nil
+		}
+		if r := m.k.Lookup(t.Name); r != nil && r.Type == lookup.TypeToplevel {
+			if spec, ok := r.Object.Decl.(*ast.TypeSpec); ok {
+				switch spec.Type.(type) {
+				case *ast.StructType, *ast.ArrayType:
+					if at, ok := spec.Type.(*ast.ArrayType); ok && at.Len == nil {
+						return "nil", nil
+					}
+					return t.Name + "{}", nil
+				default:
+					return m.zeroOfExpr(spec.Type)
+				}
+			}
+		}
+	case *ast.StarExpr, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType:
+		return "nil", nil
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "nil", nil
+		}
+	}
+
+	// fallback, e.g. the type defined in other package
+	s, err := printer.SprintCode(m.fset, expr)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := expr.(*ast.StructType); ok {
+		return s + "{}", nil
+	}
+	return "*new(" + s + ")", nil
+}
+
+func zeroOfType(typ types.Type, qf types.Qualifier) string {
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case t.Info()&types.IsBoolean != 0:
+			return "false"
+		case t.Info()&types.IsString != 0:
+			return `""`
+		case t.Info()&types.IsNumeric != 0:
+			return "0"
+		default:
+			return "nil"
+		}
+	case *types.Struct, *types.Array:
+		return types.TypeString(typ, qf) + "{}"
+	default:
+		return "nil"
+	}
+}
+
+// importPath : import path of the package named name in file (if not imported, name is treated as path, e.g. "io")
+func importPath(file *ast.File, name string) string {
+	if file != nil {
+		for _, spec := range file.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			if spec.Name != nil {
+				if spec.Name.Name == name {
+					return path
+				}
+				continue
+			}
+			if path == name || strings.HasSuffix(path, "/"+name) {
+				return path
+			}
+		}
+	}
+	return name
+}
+
+func hasImport(file *ast.File, path string) bool {
+	for _, spec := range file.Imports {
+		if spec.Path.Value == strconv.Quote(path) {
+			return true
+		}
+	}
+	return false
+}